@@ -0,0 +1,114 @@
+package robotstxt
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// agentNode is a byte trie of group agents built at parse time. Walking
+// it along a user-agent visits every group whose agent is a prefix of it,
+// the deepest one is the most specific.
+type agentNode struct {
+	labels []byte // sorted
+	next   []*agentNode
+	group  *Group
+}
+
+func newAgentIndex(groups map[string]*Group) *agentNode {
+	root := &agentNode{}
+	for a, g := range groups {
+		if a == "*" {
+			continue
+		}
+		n := root
+		for i := 0; i < len(a); i++ {
+			n = n.insert(a[i])
+		}
+		n.group = g
+	}
+	return root
+}
+
+func (n *agentNode) child(c byte) *agentNode {
+	i := sort.Search(len(n.labels), func(i int) bool { return n.labels[i] >= c })
+	if i < len(n.labels) && n.labels[i] == c {
+		return n.next[i]
+	}
+	return nil
+}
+
+func (n *agentNode) insert(c byte) *agentNode {
+	i := sort.Search(len(n.labels), func(i int) bool { return n.labels[i] >= c })
+	if i < len(n.labels) && n.labels[i] == c {
+		return n.next[i]
+	}
+	next := &agentNode{}
+	n.labels = append(n.labels, 0)
+	copy(n.labels[i+1:], n.labels[i:])
+	n.labels[i] = c
+	n.next = append(n.next, nil)
+	copy(n.next[i+1:], n.next[i:])
+	n.next[i] = next
+	return next
+}
+
+// longestPrefix returns the group with the longest agent that is a prefix
+// of agent and the length of that agent. Agent must be lower case.
+func (n *agentNode) longestPrefix(agent string) (ret *Group, l int) {
+	for i := 0; i < len(agent); i++ {
+		if n = n.child(agent[i]); n == nil {
+			break
+		}
+		if n.group != nil {
+			ret, l = n.group, i+1
+		}
+	}
+	return
+}
+
+// agentCache remembers FindGroup results by user-agent string as passed,
+// see Options.AgentCacheSize. It drops least recently used entries past
+// its size and is safe for concurrent use.
+type agentCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *agentItem, most recently used first
+	entries map[string]*list.Element
+}
+
+type agentItem struct {
+	agent string
+	group *Group
+}
+
+func newAgentCache(size int) *agentCache {
+	return &agentCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *agentCache) get(agent string) (*Group, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[agent]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*agentItem).group, true
+}
+
+func (c *agentCache) put(agent string, g *Group) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[agent]; ok {
+		el.Value.(*agentItem).group = g
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[agent] = c.order.PushFront(&agentItem{agent, g})
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*agentItem).agent)
+	}
+}
@@ -0,0 +1,118 @@
+package robotstxt
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findGroupLinear is the reference implementation of agent lookup.
+func findGroupLinear(groups map[string]*Group, agent string) (ret *Group) {
+	var prefixLen int
+	agent = strings.ToLower(agent)
+	if ret = groups["*"]; ret != nil {
+		prefixLen = 1
+	}
+	for a, g := range groups {
+		if a != "*" && strings.HasPrefix(agent, a) && len(a) > prefixLen {
+			prefixLen = len(a)
+			ret = g
+		}
+	}
+	return ret
+}
+
+func TestAgentIndexRandom(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	str := func(n int) string {
+		b := make([]byte, 1+rnd.Intn(n))
+		for i := range b {
+			b[i] = "abAB*"[rnd.Intn(5)]
+		}
+		return string(b)
+	}
+	for i := 0; i < 1000; i++ {
+		var b strings.Builder
+		for j := rnd.Intn(8); j >= 0; j-- {
+			fmt.Fprintf(&b, "User-agent: %s\nDisallow: /%d\n", str(4), j)
+		}
+		r, err := FromString(b.String())
+		require.NoError(t, err)
+		for j := 0; j < 50; j++ {
+			agent := str(6)
+			expect := findGroupLinear(r.groups, agent)
+			if expect == nil {
+				expect = emptyGroup
+			}
+			require.True(t, expect == r.FindGroup(agent), "robots.txt:\n%sagent %q", b.String(), agent)
+		}
+	}
+}
+
+func TestAgentCache(t *testing.T) {
+	t.Parallel()
+	r, err := Options{AgentCacheSize: 4}.FromString(robotsGoogle)
+	require.NoError(t, err)
+	plain, err := FromString(robotsGoogle)
+	require.NoError(t, err)
+	agents := []string{"Googlebot", "Googlebot-Image/1.0", "Googlebot-News", "Mediapartners-Google", "OtherBot", "*", ""}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, a := range agents {
+					assert.Equal(t, plain.FindGroup(a).Agent, r.FindGroup(a).Agent, a)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, r.cache.entries, 4)
+
+	// The least recently used agent is dropped.
+	for _, a := range agents[:4] {
+		r.FindGroup(a)
+	}
+	r.FindGroup(agents[0])
+	r.FindGroup(agents[4])
+	assert.Contains(t, r.cache.entries, agents[0])
+	assert.NotContains(t, r.cache.entries, agents[1])
+	assert.Equal(t, 4, r.cache.order.Len())
+
+	// Cache is keyed by the string as passed.
+	assert.True(t, r.FindGroup("GOOGLEBOT") == r.FindGroup("googlebot"))
+}
+
+func BenchmarkFindGroup(b *testing.B) {
+	benchmarkFindGroup(b, Options{})
+}
+
+func BenchmarkFindGroupCached(b *testing.B) {
+	benchmarkFindGroup(b, Options{AgentCacheSize: 16})
+}
+
+func benchmarkFindGroup(b *testing.B, o Options) {
+	var text strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&text, "User-agent: crawler%d\nDisallow: /%d\n\n", i, i)
+	}
+	r, err := o.FromString(text.String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	agents := []string{"Crawler150/2.1 (+http://example.com/bot)", "Mozilla/5.0 (compatible; OtherBot/1.0)"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.FindGroup(agents[i%len(agents)])
+	}
+}
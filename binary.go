@@ -0,0 +1,376 @@
+package robotstxt
+
+// Binary format, version 1. All integers are varints of encoding/binary,
+// strings are indexes into the string table, index 0 is "".
+//
+//	magic      "RTXT"
+//	version    uvarint, 1
+//	flags      uvarint, allowAll 1, disallowAll 2, fromStatus 4, rfc9309 8,
+//	           MatchMode << 4 if not default for rfc9309,
+//	           Precedence << 6 if not default for rfc9309, truncated 512,
+//	           fullUserAgent 1024
+//	strings    uvarint count, then count * (uvarint length, bytes)
+//	filename   string
+//	host       string
+//	origin     string, see Options.Origin
+//	etag       string, see RobotsData.ETag
+//	lastMod    string, see RobotsData.LastModified
+//	sitemaps   uvarint count, then count * string
+//	groups     uvarint count, then count * group, sorted by agent
+//
+// group:
+//
+//	agent      string
+//	crawlDelay varint, nanoseconds
+//	rules      uvarint count, then count * rule, in robots.txt order
+//
+// rule:
+//
+//	flags      uvarint, allow 1, wildcard 2
+//	path       string
+//	text       string
+//	offset     uvarint
+//	line       uvarint
+//	column     uvarint
+//
+// Identical strings are stored once. Wildcard patterns are split from path
+// on decode, it takes no compilation.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	binaryMagic   = "RTXT"
+	binaryVersion = 1
+)
+
+const (
+	binaryAllowAll = 1 << iota
+	binaryDisallowAll
+	binaryFromStatus
+	binaryRFC9309
+
+	binaryMatchShift = 4
+	binaryMatchMask  = 3 << binaryMatchShift
+
+	binaryPrecedenceShift = 6
+	binaryPrecedenceMask  = 7 << binaryPrecedenceShift
+
+	binaryTruncated     = 1 << 9
+	binaryFullUserAgent = 1 << 10
+
+	binaryFlags = binaryAllowAll | binaryDisallowAll | binaryFromStatus | binaryRFC9309 | binaryMatchMask | binaryPrecedenceMask | binaryTruncated | binaryFullUserAgent
+)
+
+const (
+	binaryRuleAllow = 1 << iota
+	binaryRuleWildcard
+)
+
+var errBinaryTruncated = errors.New("robotstxt: binary data truncated")
+
+// MarshalBinary encodes r in compact versioned format described in
+// binary.go. Decoding it with UnmarshalBinary is much faster than parsing.
+func (r *RobotsData) MarshalBinary() ([]byte, error) {
+	strs := binaryStrings{index: map[string]uint64{"": 0}}
+	agents := r.Agents()
+	var filename string
+	for _, a := range agents {
+		g := r.groups[a]
+		strs.add(g.Agent)
+		for _, rule := range g.rules {
+			strs.add(rule.path)
+			strs.add(rule.text)
+		}
+		if filename == "" {
+			filename = g.filename()
+		}
+	}
+	strs.add(filename)
+	strs.add(r.Host)
+	strs.add(r.origin)
+	strs.add(r.etag)
+	strs.add(r.lastModified)
+	for _, s := range r.Sitemaps {
+		strs.add(s)
+	}
+
+	var flags uint64
+	if r.allowAll {
+		flags |= binaryAllowAll
+	}
+	if r.disallowAll {
+		flags |= binaryDisallowAll
+	}
+	if r.fromStatus {
+		flags |= binaryFromStatus
+	}
+	if r.rfc9309 {
+		flags |= binaryRFC9309
+	}
+	if r.Truncated {
+		flags |= binaryTruncated
+	}
+	if r.fullUserAgent {
+		flags |= binaryFullUserAgent
+	}
+	if r.match != MatchDefault && r.match != MatchDefault.resolve(r.rfc9309) {
+		flags |= uint64(r.match) << binaryMatchShift
+	}
+	if r.precedence != PrecedenceDefault && r.precedence != PrecedenceDefault.resolve(r.rfc9309) {
+		flags |= uint64(r.precedence) << binaryPrecedenceShift
+	}
+
+	buf := make([]byte, 0, 16+strs.size+8*len(agents))
+	buf = append(buf, binaryMagic...)
+	buf = binary.AppendUvarint(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(len(strs.list)))
+	for _, s := range strs.list {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	buf = binary.AppendUvarint(buf, strs.index[filename])
+	buf = binary.AppendUvarint(buf, strs.index[r.Host])
+	buf = binary.AppendUvarint(buf, strs.index[r.origin])
+	buf = binary.AppendUvarint(buf, strs.index[r.etag])
+	buf = binary.AppendUvarint(buf, strs.index[r.lastModified])
+	buf = binary.AppendUvarint(buf, uint64(len(r.Sitemaps)))
+	for _, s := range r.Sitemaps {
+		buf = binary.AppendUvarint(buf, strs.index[s])
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(agents)))
+	for _, a := range agents {
+		g := r.groups[a]
+		buf = binary.AppendUvarint(buf, strs.index[g.Agent])
+		buf = binary.AppendVarint(buf, int64(g.CrawlDelay))
+		buf = binary.AppendUvarint(buf, uint64(len(g.rules)))
+		for _, rule := range g.rules {
+			var flags uint64
+			if rule.allow {
+				flags |= binaryRuleAllow
+			}
+			if rule.pattern != nil {
+				flags |= binaryRuleWildcard
+			}
+			buf = binary.AppendUvarint(buf, flags)
+			buf = binary.AppendUvarint(buf, strs.index[rule.path])
+			buf = binary.AppendUvarint(buf, strs.index[rule.text])
+			buf = binary.AppendUvarint(buf, uint64(rule.pos.Offset))
+			buf = binary.AppendUvarint(buf, uint64(rule.pos.Line))
+			buf = binary.AppendUvarint(buf, uint64(rule.pos.Column))
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes r from format produced by MarshalBinary.
+func (r *RobotsData) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic) || string(data[:len(binaryMagic)]) != binaryMagic {
+		return errors.New("robotstxt: not a binary robots data")
+	}
+	d := binaryDecoder{data: data[len(binaryMagic):]}
+	version := d.uvarint()
+	if d.err == nil && version != binaryVersion {
+		return fmt.Errorf("robotstxt: unsupported binary version %d", version)
+	}
+	flags := d.uvarint()
+	if flags&^binaryFlags != 0 && d.err == nil {
+		d.err = fmt.Errorf("robotstxt: unknown binary flags %#x", flags&^binaryFlags)
+	}
+	if p := flags & binaryPrecedenceMask >> binaryPrecedenceShift; p >= uint64(len(precedenceNames)) && d.err == nil {
+		d.err = fmt.Errorf("robotstxt: unknown binary precedence %d", p)
+	}
+	d.readStrings()
+	filename := d.str()
+	decoded := RobotsData{
+		Host:        d.str(),
+		Truncated:   flags&binaryTruncated != 0,
+		allowAll:    flags&binaryAllowAll != 0,
+		disallowAll: flags&binaryDisallowAll != 0,
+		fromStatus:  flags&binaryFromStatus != 0,
+		rfc9309:     flags&binaryRFC9309 != 0,
+		match:       MatchMode(flags & binaryMatchMask >> binaryMatchShift),
+		precedence:  Precedence(flags & binaryPrecedenceMask >> binaryPrecedenceShift),
+
+		fullUserAgent: flags&binaryFullUserAgent != 0,
+	}
+	decoded.match = decoded.match.resolve(decoded.rfc9309)
+	decoded.precedence = decoded.precedence.resolve(decoded.rfc9309)
+	decoded.origin = d.str()
+	decoded.etag = d.str()
+	decoded.lastModified = d.str()
+	if n := d.count(); n > 0 {
+		decoded.Sitemaps = make([]string, n)
+		for i := range decoded.Sitemaps {
+			decoded.Sitemaps[i] = d.str()
+		}
+	}
+	n := d.count()
+	decoded.groups = make(map[string]*Group, n)
+	for ; n > 0 && d.err == nil; n-- {
+		g := &Group{Agent: d.str()}
+		if g.Agent == "" && d.err == nil {
+			d.err = errors.New("robotstxt: binary group without agent")
+		}
+		if _, ok := decoded.groups[g.Agent]; ok && d.err == nil {
+			d.err = fmt.Errorf("robotstxt: duplicate group %q", g.Agent)
+		}
+		g.CrawlDelay = time.Duration(d.varint())
+		if g.CrawlDelay < 0 && d.err == nil {
+			d.err = fmt.Errorf("robotstxt: group %q: negative crawl delay", g.Agent)
+		}
+		g.rules = make([]*rule, d.count())
+		for i := range g.rules {
+			rule := d.rule()
+			rule.pos.Filename = filename
+			g.rules[i] = rule
+		}
+		decoded.groups[g.Agent] = g
+	}
+	if d.err == nil && len(d.data) != 0 {
+		d.err = fmt.Errorf("robotstxt: %d bytes of trailing binary data", len(d.data))
+	}
+	if d.err != nil {
+		return d.err
+	}
+	setPrecedence(decoded.groups, decoded.precedence)
+	decoded.indexAgents()
+	*r = decoded
+	return nil
+}
+
+type binaryStrings struct {
+	list  []string
+	index map[string]uint64
+	size  int
+}
+
+func (s *binaryStrings) add(str string) {
+	if _, ok := s.index[str]; ok {
+		return
+	}
+	s.list = append(s.list, str)
+	s.index[str] = uint64(len(s.list))
+	s.size += len(str) + 2
+}
+
+// binaryDecoder reads data, the first error stops decoding and is kept in
+// err, following reads return zero values.
+type binaryDecoder struct {
+	data    []byte
+	strings []string
+	err     error
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errBinaryTruncated
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = errBinaryTruncated
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// count reads number of following items, each takes at least one byte.
+func (d *binaryDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		if d.err == nil {
+			d.err = errBinaryTruncated
+		}
+		return 0
+	}
+	return int(n)
+}
+
+// readStrings reads the string table. All strings share one allocation.
+func (d *binaryDecoder) readStrings() {
+	n := d.count()
+	lens := make([]int, n)
+	start, total := d.data, 0
+	for i := range lens {
+		l := d.uvarint()
+		if l > uint64(len(d.data)) {
+			if d.err == nil {
+				d.err = errBinaryTruncated
+			}
+			return
+		}
+		lens[i] = int(l)
+		total += int(l)
+		d.data = d.data[l:]
+	}
+	if d.err != nil {
+		return
+	}
+	all := make([]byte, 0, total)
+	for _, l := range lens {
+		_, k := binary.Uvarint(start)
+		all = append(all, start[k:k+l]...)
+		start = start[k+l:]
+	}
+	s := string(all)
+	d.strings = make([]string, n+1)
+	for i, l := range lens {
+		d.strings[i+1], s = s[:l], s[l:]
+	}
+}
+
+func (d *binaryDecoder) str() string {
+	i := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if i == 0 {
+		return ""
+	}
+	if i >= uint64(len(d.strings)) {
+		d.err = fmt.Errorf("robotstxt: binary string index %d out of range", i)
+		return ""
+	}
+	return d.strings[i]
+}
+
+// rule reads a rule, path must be normalized as by parsePath and wildcard
+// flag must agree with it.
+func (d *binaryDecoder) rule() *rule {
+	flags := d.uvarint()
+	r := &rule{allow: flags&binaryRuleAllow != 0, path: d.str(), text: d.str()}
+	if r.path == "" && d.err == nil {
+		d.err = errors.New("robotstxt: binary rule without path")
+	}
+	var path string
+	path, r.pattern = parsePath(r.path)
+	if path != r.path && d.err == nil {
+		d.err = fmt.Errorf("robotstxt: binary rule path %q must start with \"/\" or \"*\", not end with \"*\" and have normalized percent-encoding, expected %q", r.path, path)
+	}
+	if (flags&binaryRuleWildcard != 0) != (r.pattern != nil) && d.err == nil {
+		d.err = fmt.Errorf("robotstxt: binary rule %q wildcard flag does not match path", r.path)
+	}
+	r.pos.Offset = int(d.uvarint())
+	r.pos.Line = int(d.uvarint())
+	r.pos.Column = int(d.uvarint())
+	return r
+}
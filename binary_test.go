@@ -0,0 +1,104 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+	inputs := []string{robotsText001, robotsGoogle, robotsCaseMatching, robotsCasePrecedence, robotsTextVanityfair,
+		"User-agent: FooBot\nCrawl-delay: 2.5\nDisallow: private*.gif$\nAllow: /private/\nSitemap: https://example.com/sitemap.xml\nHost: example.com\n"}
+	for _, o := range []Options{{}, {RFC9309: true}} {
+		for _, input := range inputs {
+			r, err := o.FromString(input)
+			require.NoError(t, err)
+			data, err := r.MarshalBinary()
+			require.NoError(t, err)
+			var decoded RobotsData
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			again, err := decoded.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, data, again)
+
+			assert.Equal(t, r.String(), decoded.String())
+			assert.Equal(t, r.Sitemaps, decoded.Sitemaps)
+			for _, a := range r.Agents() {
+				assert.Equal(t, r.FindGroup(a).Rules(), decoded.FindGroup(a).Rules())
+				assert.Equal(t, r.FindGroup(a).CrawlDelay, decoded.FindGroup(a).CrawlDelay)
+			}
+			f := func(path, agent string) bool {
+				return r.TestAgent(path, agent) == decoded.TestAgent(path, agent)
+			}
+			require.NoError(t, quick.Check(f, nil))
+
+			jsonData, err := json.Marshal(r)
+			require.NoError(t, err)
+			assert.True(t, len(data) < len(jsonData)*3/5, "binary %d, JSON %d bytes", len(data), len(jsonData))
+		}
+	}
+}
+
+func TestBinaryFullUserAgent(t *testing.T) {
+	t.Parallel()
+	const header = "Mozilla/5.0 (compatible; Googlebot/2.1)"
+	r, err := Options{FullUserAgent: true}.FromString("User-agent: googlebot\nDisallow: /\n")
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", header)
+	data, err := r.MarshalBinary()
+	require.NoError(t, err)
+	var decoded RobotsData
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	expectAccess(t, &decoded, false, "/", header)
+}
+
+func TestBinaryShortcuts(t *testing.T) {
+	t.Parallel()
+	for _, r := range []*RobotsData{allowAll, disallowAll, emptyRobots} {
+		data, err := r.MarshalBinary()
+		require.NoError(t, err)
+		var decoded RobotsData
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		assert.Equal(t, r.Explain("/", "bot"), decoded.Explain("/", "bot"))
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	t.Parallel()
+	r, err := FromString(robotsText001)
+	require.NoError(t, err)
+	data, err := r.MarshalBinary()
+	require.NoError(t, err)
+	// Every truncation must fail cleanly.
+	for i := 0; i < len(data); i++ {
+		var decoded RobotsData
+		assert.Error(t, decoded.UnmarshalBinary(data[:i]), "length %d", i)
+	}
+
+	cases := map[string]string{
+		"":                     "not a binary robots data",
+		"{}":                   "not a binary robots data",
+		"RTXT\x00":             "unsupported binary version 0",
+		"RTXT\x02":             "unsupported binary version 2",
+		"RTXT\x01\x00\x00":     "binary data truncated",
+		"RTXT\x01\x00\x00\x05": "out of range",
+		"RTXT\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00":                                                "trailing binary data",
+		"RTXT\x01\x00\x01\x01a\x00\x00\x00\x00\x00\x00\x02\x01\x00\x00\x01\x00\x00":                       `duplicate group "a"`,
+		"RTXT\x01\x00\x01\x01a\x00\x00\x00\x00\x00\x00\x01\x01\x01\x00":                                   "negative crawl delay",
+		"RTXT\x01\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00":                                        "binary group without agent",
+		"RTXT\x01\x00\x01\x01a\x00\x00\x00\x00\x00\x00\x01\x01\x00\x01\x00\x00\x00\x00\x00\x00":           "binary rule without path",
+		"RTXT\x01\x00\x02\x01a\x02/x\x00\x00\x00\x00\x00\x00\x01\x01\x00\x01\x02\x02\x00\x00\x00\x00":     "wildcard flag does not match path",
+		"RTXT\x01\x00\x02\x01a\x06/a%2fb\x00\x00\x00\x00\x00\x00\x01\x01\x00\x01\x00\x02\x00\x00\x00\x00": `expected "/a%2Fb"`,
+		"RTXT\x01\x00\x02\x01a\x06xa%2Fb\x00\x00\x00\x00\x00\x00\x01\x01\x00\x01\x00\x02\x00\x00\x00\x00": `expected "/xa%2Fb"`,
+	}
+	for input, expect := range cases {
+		var decoded RobotsData
+		err := decoded.UnmarshalBinary([]byte(input))
+		require.Error(t, err, "%q", input)
+		assert.Contains(t, err.Error(), expect, "%q", input)
+	}
+}
@@ -0,0 +1,183 @@
+package robotstxt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Builder constructs RobotsData in code. Methods record directives in
+// order and can be chained:
+//
+//	r, err := robotstxt.NewBuilder().
+//		Group("googlebot").Disallow("/private").Allow("/private/ok").
+//		CrawlDelay(2 * time.Second).
+//		Sitemap("https://example.com/sitemap.xml").
+//		Build()
+//
+// Invalid values are validated the same way as parsed robots.txt and
+// reported by Build and Text.
+type Builder struct {
+	o        Options
+	groups   []*builderGroup
+	host     string
+	sitemaps []string
+	errs     []error
+}
+
+type builderGroup struct {
+	agents     []string
+	rules      []*rule
+	crawlDelay time.Duration
+}
+
+// NewBuilder returns a Builder with default Options.
+func NewBuilder() *Builder {
+	return Options{}.NewBuilder()
+}
+
+// NewBuilder returns a Builder producing RobotsData as if parsed with o.
+func (o Options) NewBuilder() *Builder {
+	return &Builder{o: o}
+}
+
+// Group starts a new group for agents, following rules and crawl delay
+// apply to it. Agents are not case-sensitive.
+func (b *Builder) Group(agents ...string) *Builder {
+	g := &builderGroup{}
+	if len(agents) == 0 {
+		b.errs = append(b.errs, errors.New("Group without agents"))
+	}
+	for _, a := range agents {
+		if err := checkValue(a); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("User-agent %q: %w", a, err))
+			continue
+		}
+		g.agents = append(g.agents, strings.ToLower(a))
+	}
+	b.groups = append(b.groups, g)
+	return b
+}
+
+// Allow adds an Allow rule to the current group.
+func (b *Builder) Allow(path string) *Builder {
+	return b.addRule("Allow", path, true)
+}
+
+// Disallow adds a Disallow rule to the current group.
+func (b *Builder) Disallow(path string) *Builder {
+	return b.addRule("Disallow", path, false)
+}
+
+func (b *Builder) addRule(key, value string, allow bool) *Builder {
+	g := b.current(key)
+	if g == nil {
+		return b
+	}
+	if err := checkValue(value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s %q: %w", key, value, err))
+		return b
+	}
+	path, pattern := parsePath(value)
+	g.rules = append(g.rules, &rule{path: path, allow: allow, pattern: pattern, text: value})
+	return b
+}
+
+// CrawlDelay sets crawl delay of the current group.
+func (b *Builder) CrawlDelay(d time.Duration) *Builder {
+	g := b.current("Crawl-delay")
+	if g == nil {
+		return b
+	}
+	if err := checkCrawlDelay(d.Seconds(), d.String()); err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	g.crawlDelay = d
+	return b
+}
+
+// Sitemap adds a sitemap URL, it does not belong to any group.
+func (b *Builder) Sitemap(url string) *Builder {
+	if err := checkValue(url); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Sitemap %q: %w", url, err))
+		return b
+	}
+	b.sitemaps = append(b.sitemaps, url)
+	return b
+}
+
+// Host sets main site mirror, it does not belong to any group.
+func (b *Builder) Host(host string) *Builder {
+	if err := checkValue(host); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Host %q: %w", host, err))
+		return b
+	}
+	b.host = host
+	return b
+}
+
+// Build returns RobotsData of all recorded directives or all validation
+// errors joined.
+func (b *Builder) Build() (*RobotsData, error) {
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+	origin, err := b.o.origin()
+	if err != nil {
+		return nil, err
+	}
+	r := &RobotsData{
+		Host:     b.host,
+		Sitemaps: append([]string(nil), b.sitemaps...),
+		rfc9309:  b.o.RFC9309,
+		origin:   origin,
+		groups:   make(map[string]*Group, len(b.groups)),
+	}
+	for _, bg := range b.groups {
+		parseGroupMap(r.groups, bg.agents, func(g *Group) {
+			g.rules = append(g.rules, bg.rules...)
+			if bg.crawlDelay != 0 {
+				g.CrawlDelay = bg.crawlDelay
+			}
+		})
+	}
+	b.o.index(r)
+	return r, nil
+}
+
+// Text returns canonical robots.txt text of Build result.
+func (b *Builder) Text() (string, error) {
+	r, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+func (b *Builder) current(key string) *builderGroup {
+	if len(b.groups) == 0 {
+		b.errs = append(b.errs, errors.New(key+" before User-agent"))
+		return nil
+	}
+	return b.groups[len(b.groups)-1]
+}
+
+// checkValue reports values that can not be written as a single
+// robots.txt token.
+func checkValue(value string) error {
+	if value == "" {
+		return errors.New("empty value")
+	}
+	if strings.HasPrefix(value, "#") {
+		return errors.New("value starts a comment")
+	}
+	for i, c := range value {
+		if c == ' ' || c == '\t' || c == '\v' || c == '\r' || c == '\n' {
+			return errors.New("whitespace at byte " + strconv.Itoa(i))
+		}
+	}
+	return nil
+}
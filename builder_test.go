@@ -0,0 +1,111 @@
+package robotstxt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+	b := NewBuilder().
+		Group("Googlebot").Disallow("/private").Allow("/private/ok").CrawlDelay(2*time.Second).
+		Sitemap("https://example.com/sitemap.xml").
+		Group("*").Disallow("/*.gif$").
+		Group("quxbot", "barbot").
+		Host("example.com")
+	r, err := b.Build()
+	require.NoError(t, err)
+
+	expectAccess(t, r, false, "/private", "googlebot")
+	expectAccess(t, r, true, "/private/ok", "Googlebot")
+	expectAccess(t, r, true, "/a.gif", "googlebot")
+	expectAccess(t, r, false, "/a.gif", "otherbot")
+	expectAccess(t, r, true, "/a.gif", "quxbot")
+	assert.Equal(t, 2*time.Second, r.FindGroup("googlebot").CrawlDelay)
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, r.Sitemaps)
+	assert.Equal(t, "example.com", r.Host)
+
+	text, err := b.Text()
+	require.NoError(t, err)
+	assert.Equal(t, `User-agent: *
+Disallow: /*.gif$
+
+User-agent: barbot
+User-agent: quxbot
+Disallow:
+
+User-agent: googlebot
+Crawl-delay: 2
+Disallow: /private
+Allow: /private/ok
+
+Sitemap: https://example.com/sitemap.xml
+Host: example.com
+`, text)
+
+	parsed, err := FromString(text)
+	require.NoError(t, err)
+	assert.Equal(t, text, parsed.String())
+	rules := r.FindGroup("googlebot").Rules()
+	assert.Equal(t, parsed.FindGroup("googlebot").Rules()[0].Path, rules[0].Path)
+	assert.Equal(t, "/private", rules[0].Text)
+}
+
+func TestBuilderSameAgent(t *testing.T) {
+	t.Parallel()
+	r, err := NewBuilder().
+		Group("a", "bot").Disallow("/a").
+		Group("bot").Disallow("/b").
+		Build()
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/a", "bot")
+	expectAccess(t, r, false, "/b", "bot")
+	assert.Len(t, r.FindGroup("bot").Rules(), 2)
+}
+
+func TestBuilderRFC9309(t *testing.T) {
+	t.Parallel()
+	r, err := Options{RFC9309: true}.NewBuilder().
+		Group("foobot").Disallow("/caf%c3%a9").Allow("/caf%C3%A9").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, "/caf%C3%A9", r.FindGroup("foobot").Rules()[0].Path)
+	expectAccess(t, r, true, "/café", "foobot")
+	expectAccess(t, r, true, "/caf", "FooBot/1.0")
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		build  func(*Builder)
+		expect string
+	}{
+		{"rule-before-group", func(b *Builder) { b.Disallow("/") }, "Disallow before User-agent"},
+		{"delay-before-group", func(b *Builder) { b.CrawlDelay(time.Second) }, "Crawl-delay before User-agent"},
+		{"no-agents", func(b *Builder) { b.Group() }, "Group without agents"},
+		{"empty-agent", func(b *Builder) { b.Group("") }, `User-agent "": empty value`},
+		{"space-agent", func(b *Builder) { b.Group("foo bot") }, `User-agent "foo bot": whitespace at byte 3`},
+		{"empty-path", func(b *Builder) { b.Group("a").Allow("") }, `Allow "": empty value`},
+		{"newline-path", func(b *Builder) { b.Group("a").Disallow("/a\nAllow: /") }, `Disallow "/a\nAllow: /": whitespace at byte 2`},
+		{"comment-path", func(b *Builder) { b.Group("a").Disallow("#/") }, `value starts a comment`},
+		{"negative-delay", func(b *Builder) { b.Group("a").CrawlDelay(-2 * time.Second) }, "Crawl-delay invalid value '-2s'"},
+		{"sitemap", func(b *Builder) { b.Sitemap("http://x/ y") }, `Sitemap "http://x/ y"`},
+		{"host", func(b *Builder) { b.Host("") }, `Host "": empty value`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := NewBuilder()
+			c.build(b)
+			r, err := b.Build()
+			require.Error(t, err)
+			assert.Nil(t, r)
+			assert.Contains(t, err.Error(), c.expect)
+			_, err = b.Text()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package robotstxt
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheMaxAge limits how long Cache keeps using robots.txt.
+// From RFC 9309:
+// Crawlers SHOULD NOT use the cached version for more than 24 hours,
+// unless the robots.txt file is unreachable.
+const DefaultCacheMaxAge = 24 * time.Hour
+
+// DefaultCacheErrorTTL is how long Cache remembers a failed fetch.
+const DefaultCacheErrorTTL = 10 * time.Minute
+
+// CacheEntry is robots.txt of an origin as Cache stores it.
+type CacheEntry struct {
+	// Robots is the fetched robots data, for a failed fetch the shared
+	// instance disallowing everything, without origin.
+	Robots *RobotsData
+	// Err is the error of a failed fetch, Cache.Get does not return it.
+	Err error
+	// Expires is when the entry must be fetched again.
+	Expires time.Time
+}
+
+// Store keeps Cache entries by origin. It must be safe for concurrent use.
+// Expired entries are still returned, Cache decides what to do with them.
+type Store interface {
+	Get(origin string) (CacheEntry, bool)
+	Put(origin string, e CacheEntry)
+}
+
+// Cache fetches robots.txt of a site once and reuses it for all its pages
+// until it expires. Entries are keyed by origin, scheme://host[:port] of
+// the site. It is safe for concurrent use, but concurrent calls for the
+// same expired origin may fetch it several times.
+type Cache struct {
+	// Fetcher downloads robots.txt.
+	Fetcher Fetcher
+
+	// Store keeps entries, see NewCache.
+	Store Store
+
+	// MaxAge caps the time a response is used, whatever its Cache-Control
+	// and Expires headers say. It is also used when there are no such
+	// headers. DefaultCacheMaxAge when zero.
+	MaxAge time.Duration
+
+	// ErrorTTL is the time a failed fetch is remembered, including a 5xx
+	// status. DefaultCacheErrorTTL when zero.
+	ErrorTTL time.Duration
+
+	now func() time.Time // time.Now if nil, for tests
+
+	hits, misses, notModified, modified atomic.Int64
+}
+
+// CacheStats counts how Cache.Get got its results.
+type CacheStats struct {
+	// Hits are results from fresh entries, without a request.
+	Hits int64
+	// Misses are unconditional requests, without an entry to revalidate.
+	Misses int64
+	// NotModified are conditional requests answered with 304 Not Modified,
+	// the entry was reused.
+	NotModified int64
+	// Modified are conditional requests answered otherwise.
+	Modified int64
+}
+
+// NewCache returns Cache storing robots.txt of up to size origins in memory,
+// see NewMemoryStore.
+func NewCache(size int) *Cache {
+	return &Cache{Store: NewMemoryStore(size)}
+}
+
+// Get returns robots data of the site of pageURL, fetching it if it is not
+// cached or expired. An expired entry with RobotsData.ETag or LastModified
+// is revalidated with a conditional request, see Fetcher.Revalidate.
+// A failed fetch disallows everything for ErrorTTL, as RFC 9309 requires
+// for unreachable robots.txt, its error is kept in CacheEntry.Err. If
+// Fetcher.Options.StatusPolicy maps the status to StatusUseCached, the
+// expired entry is kept for ErrorTTL more, without one it is a failed fetch.
+// Only an invalid pageURL and cancellation of ctx are returned as errors,
+// the latter is not cached.
+func (c *Cache) Get(ctx context.Context, pageURL string) (*RobotsData, error) {
+	robotsURL, err := RobotsURL(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	origin, err := parseOrigin(robotsURL)
+	if err != nil {
+		return nil, err
+	}
+	now := c.clock()
+	entry, cached := c.Store.Get(origin)
+	if cached && now.Before(entry.Expires) {
+		c.hits.Add(1)
+		return entry.Robots, nil
+	}
+
+	var stale *RobotsData
+	if cached && entry.Err == nil {
+		stale = entry.Robots
+	}
+	r, res, err := c.Fetcher.fetch(ctx, pageURL, stale)
+	switch {
+	case stale == nil || stale.etag == "" && stale.lastModified == "":
+		c.misses.Add(1)
+	case res != nil && res.StatusCode == http.StatusNotModified && r == stale:
+		c.notModified.Add(1)
+	default:
+		c.modified.Add(1)
+	}
+	switch {
+	case r == nil && ctx.Err() != nil:
+		return nil, err
+	case errors.Is(err, ErrUseCached) && cached && entry.Err == nil:
+		entry.Expires = now.Add(c.errorTTL())
+	case err != nil:
+		entry = CacheEntry{Robots: disallowAll, Err: err, Expires: now.Add(c.errorTTL())}
+	case r.disallowAll && r.fromStatus:
+		// Unreachable, status 5xx.
+		entry = CacheEntry{Robots: r, Expires: now.Add(c.errorTTL())}
+	default:
+		entry = CacheEntry{Robots: r, Expires: now.Add(c.freshness(res, now))}
+	}
+	c.Store.Put(origin, entry)
+	return entry.Robots, nil
+}
+
+// Stats returns counters of Get results so far.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		NotModified: c.notModified.Load(),
+		Modified:    c.modified.Load(),
+	}
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) maxAge() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return DefaultCacheMaxAge
+}
+
+func (c *Cache) errorTTL() time.Duration {
+	if c.ErrorTTL > 0 {
+		return c.ErrorTTL
+	}
+	return DefaultCacheErrorTTL
+}
+
+// freshness returns how long res may be used, at most MaxAge.
+// From RFC 9309:
+// Crawlers MAY use standard cache control as defined in [RFC9111].
+func (c *Cache) freshness(res *http.Response, now time.Time) time.Duration {
+	ttl := c.maxAge()
+	if res == nil {
+		return ttl
+	}
+	if t, ok := responseFreshness(res.Header, now); ok && t < ttl {
+		ttl = t
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// responseFreshness returns freshness lifetime left of a response with
+// header h as RFC 9111 defines it, false if h does not tell.
+func responseFreshness(h http.Header, now time.Time) (time.Duration, bool) {
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store", "no-cache":
+				return 0, true
+			case "max-age":
+				seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+				if err != nil {
+					// From RFC 9111: a cache MUST consider an invalid
+					// max-age stale.
+					return 0, true
+				}
+				age, _ := strconv.ParseInt(h.Get("Age"), 10, 64)
+				return time.Duration(seconds-max(age, 0)) * time.Second, true
+			}
+		}
+	}
+	if v := h.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			// From RFC 9111: a cache recipient MUST interpret invalid date
+			// formats, especially the value "0", as representing a time in
+			// the past.
+			return 0, true
+		}
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			return expires.Sub(date), true
+		}
+		return expires.Sub(now), true
+	}
+	return 0, false
+}
+
+// MemoryStore is Store in memory that drops least recently used entries
+// past its size.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *memoryItem, most recently used first
+	entries map[string]*list.Element
+}
+
+type memoryItem struct {
+	origin string
+	entry  CacheEntry
+}
+
+// NewMemoryStore returns MemoryStore of up to size entries, unbounded if
+// size is not positive.
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (m *MemoryStore) Get(origin string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[origin]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+func (m *MemoryStore) Put(origin string, e CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[origin]; ok {
+		el.Value.(*memoryItem).entry = e
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[origin] = m.order.PushFront(&memoryItem{origin, e})
+	if m.size > 0 && m.order.Len() > m.size {
+		last := m.order.Back()
+		m.order.Remove(last)
+		delete(m.entries, last.Value.(*memoryItem).origin)
+	}
+}
+
+// Len returns the number of entries.
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
@@ -0,0 +1,362 @@
+package robotstxt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheServer serves robots.txt with status and headers set by the test,
+// counting requests.
+type cacheServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	header   http.Header
+	requests atomic.Int32
+}
+
+func newCacheServer() *cacheServer {
+	s := &cacheServer{status: 200, header: http.Header{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		for k, v := range s.header {
+			w.Header()[k] = v
+		}
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	}))
+	return s
+}
+
+func (s *cacheServer) set(status int, header ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.header = http.Header{}
+	for i := 0; i < len(header); i += 2 {
+		s.header.Set(header[i], header[i+1])
+	}
+}
+
+// fakeClock is Cache.now moved by tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestCache(size int) (*Cache, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewCache(size)
+	c.now = clock.now
+	return c, clock
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	server := newCacheServer()
+	defer server.Close()
+	c, clock := newTestCache(10)
+	ctx := context.Background()
+
+	for _, page := range []string{"/", "/a/b?c", "/private"} {
+		r, err := c.Get(ctx, server.URL+page)
+		require.NoError(t, err)
+		expectAccess(t, r, false, "/private", "bot")
+	}
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	// Without caching headers robots.txt is used for DefaultCacheMaxAge.
+	clock.add(DefaultCacheMaxAge - time.Second)
+	_, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.requests.Load())
+	clock.add(time.Second)
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+
+	_, err = c.Get(ctx, "/relative")
+	assert.Error(t, err)
+}
+
+func TestCacheHeaders(t *testing.T) {
+	t.Parallel()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	cases := []struct {
+		header []string
+		ttl    time.Duration
+	}{
+		{[]string{"Cache-Control", "max-age=60"}, time.Minute},
+		{[]string{"Cache-Control", "public, max-age=120", "Age", "60"}, time.Minute},
+		{[]string{"Cache-Control", "max-age=86401"}, DefaultCacheMaxAge},
+		{[]string{"Cache-Control", "no-store"}, 0},
+		{[]string{"Cache-Control", "no-cache"}, 0},
+		{[]string{"Cache-Control", "max-age=abc"}, 0},
+		{[]string{"Cache-Control", "max-age=60", "Expires", "0"}, time.Minute},
+		{[]string{"Expires", "0"}, 0},
+		{[]string{"Expires", time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC).Format(http.TimeFormat), "Date", date}, 2 * time.Hour},
+		{[]string{"Expires", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat), "Date", date}, 0},
+	}
+	for _, tc := range cases {
+		server := newCacheServer()
+		server.set(200, tc.header...)
+		c, clock := newTestCache(10)
+		ctx := context.Background()
+
+		_, err := c.Get(ctx, server.URL)
+		require.NoError(t, err)
+		if tc.ttl > 0 {
+			clock.add(tc.ttl - time.Second)
+			_, err = c.Get(ctx, server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), server.requests.Load(), "%v", tc.header)
+			clock.add(time.Second)
+		}
+		_, err = c.Get(ctx, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), server.requests.Load(), "%v", tc.header)
+		server.Close()
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	t.Parallel()
+	server := newCacheServer()
+	defer server.Close()
+	server.set(404, "Cache-Control", "max-age=3600")
+	c, clock := newTestCache(10)
+	c.MaxAge = time.Minute
+	ctx := context.Background()
+
+	r, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/private", "bot")
+	clock.add(time.Minute)
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+}
+
+func TestCacheErrors(t *testing.T) {
+	t.Parallel()
+	server := newCacheServer()
+	defer server.Close()
+	server.set(503, "Cache-Control", "max-age=3600")
+	c, clock := newTestCache(10)
+	ctx := context.Background()
+
+	r, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", "bot")
+	assert.Equal(t, strings.ToLower(server.URL), r.Origin())
+	clock.add(DefaultCacheErrorTTL - time.Second)
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.requests.Load())
+	clock.add(time.Second)
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+
+	// A network error disallows everything, the error is kept in the entry.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	c.ErrorTTL = time.Minute
+	r, err = c.Get(ctx, down.URL)
+	require.NoError(t, err)
+	assert.True(t, r == disallowAll)
+	entry, ok := c.Store.Get(strings.ToLower(down.URL))
+	require.True(t, ok)
+	assert.Error(t, entry.Err)
+	r2, err := c.Get(ctx, down.URL)
+	require.NoError(t, err)
+	assert.True(t, r == r2)
+
+	// A parse error too.
+	server2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "Disallow: /orphan\n")
+	}))
+	defer server2.Close()
+	r, err = c.Get(ctx, server2.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", "bot")
+	entry, ok = c.Store.Get(strings.ToLower(server2.URL))
+	require.True(t, ok)
+	var pe *ParseError
+	assert.True(t, errors.As(entry.Err, &pe), "%v", entry.Err)
+
+	// Cancellation is not cached.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	clock.add(time.Hour)
+	r, err = c.Get(cancelled, server.URL)
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+	r, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/private", "bot")
+}
+
+func TestCacheUseCached(t *testing.T) {
+	t.Parallel()
+	server := newCacheServer()
+	defer server.Close()
+	c, clock := newTestCache(10)
+	c.Fetcher.Options.StatusPolicy = StatusPolicy{{From: 500, To: 599, Outcome: StatusUseCached}}
+	ctx := context.Background()
+
+	server.set(503)
+	r, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", "bot")
+	entry, ok := c.Store.Get(strings.ToLower(server.URL))
+	require.True(t, ok)
+	assert.True(t, errors.Is(entry.Err, ErrUseCached), "%v", entry.Err)
+
+	clock.add(DefaultCacheErrorTTL)
+	server.set(200)
+	r, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/", "bot")
+
+	clock.add(DefaultCacheMaxAge)
+	server.set(500)
+	for i := 0; i < 2; i++ {
+		r2, err := c.Get(ctx, server.URL)
+		require.NoError(t, err)
+		assert.True(t, r == r2)
+	}
+	assert.Equal(t, int32(3), server.requests.Load())
+	clock.add(DefaultCacheErrorTTL)
+	r2, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.True(t, r == r2)
+	assert.Equal(t, int32(4), server.requests.Load())
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	t.Parallel()
+	server := newCacheServer()
+	defer server.Close()
+	server.set(200, "Cache-Control", "no-store")
+	c := NewCache(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				r, err := c.Get(context.Background(), server.URL)
+				assert.NoError(t, err)
+				expectAccess(t, r, false, "/private", "bot")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+	m := NewMemoryStore(2)
+	for _, origin := range []string{"a", "b"} {
+		m.Put(origin, CacheEntry{Robots: allowAll})
+	}
+	_, ok := m.Get("a")
+	assert.True(t, ok)
+	m.Put("c", CacheEntry{Robots: disallowAll})
+	assert.Equal(t, 2, m.Len())
+	_, ok = m.Get("b")
+	assert.False(t, ok)
+	m.Put("a", CacheEntry{Robots: disallowAll})
+	e, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.True(t, e.Robots == disallowAll)
+	assert.Equal(t, 2, m.Len())
+
+	unbounded := NewMemoryStore(0)
+	for i := 0; i < 100; i++ {
+		unbounded.Put(fmt.Sprint(i), CacheEntry{})
+	}
+	assert.Equal(t, 100, unbounded.Len())
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	t.Parallel()
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		current := etag.Load().(string)
+		w.Header().Set("ETag", current)
+		w.Header().Set("Cache-Control", "max-age=60")
+		if req.Header.Get("If-None-Match") == current {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprintf(w, "User-agent: *\nDisallow: /%s\n", strings.Trim(current, `"`))
+	}))
+	defer server.Close()
+	c, clock := newTestCache(10)
+	ctx := context.Background()
+
+	r, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/v1", "bot")
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, c.Stats())
+
+	// Not modified: same data, fresh for max-age of 304 response.
+	clock.add(time.Minute)
+	r2, err := c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.True(t, r == r2)
+	clock.add(time.Minute - time.Second)
+	_, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, NotModified: 1}, c.Stats())
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Modified.
+	etag.Store(`"v2"`)
+	clock.add(time.Second)
+	r2, err = c.Get(ctx, server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r2, false, "/v2", "bot")
+	expectAccess(t, r2, true, "/v1", "bot")
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, NotModified: 1, Modified: 1}, c.Stats())
+
+	// Another origin.
+	other := newCacheServer()
+	defer other.Close()
+	_, err = c.Get(ctx, other.URL)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, NotModified: 1, Modified: 1}, c.Stats())
+}
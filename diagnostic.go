@@ -0,0 +1,97 @@
+package robotstxt
+
+import (
+	"go/token"
+	"strconv"
+)
+
+// DiagnosticKind classifies the problem described by a Diagnostic.
+type DiagnosticKind int
+
+const (
+	// KindRuleOutsideGroup is an Allow, Disallow or Crawl-delay line
+	// before any User-agent line.
+	KindRuleOutsideGroup DiagnosticKind = iota + 1
+	// KindInvalidCrawlDelay is a Crawl-delay value that is not
+	// a non-negative number of seconds.
+	KindInvalidCrawlDelay
+	// KindInvalidUTF8 is a byte sequence that is not valid UTF-8,
+	// it is read as U+FFFD.
+	KindInvalidUTF8
+	// KindNULByte is a NUL character in content.
+	KindNULByte
+	// KindLineTooLong is a line longer than the parser accepts,
+	// the rest of the line is ignored.
+	KindLineTooLong
+	// KindByteOrderMark is a byte order mark anywhere but at the start
+	// of content.
+	KindByteOrderMark
+	// KindTruncated is content past Options.MaxSize, it is ignored.
+	KindTruncated
+	// KindRuleTooLong is an Allow or Disallow path cut by the line length
+	// limit, the rule is ignored rather than matched as a shorter path.
+	KindRuleTooLong
+)
+
+var kindNames = map[DiagnosticKind]string{
+	KindRuleOutsideGroup:  "rule-outside-group",
+	KindInvalidCrawlDelay: "invalid-crawl-delay",
+	KindInvalidUTF8:       "invalid-utf8",
+	KindNULByte:           "nul-byte",
+	KindLineTooLong:       "line-too-long",
+	KindByteOrderMark:     "byte-order-mark",
+	KindTruncated:         "truncated",
+	KindRuleTooLong:       "rule-too-long",
+}
+
+func (k DiagnosticKind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return "kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Severity tells whether a Diagnostic fails strict parsing.
+type Severity int
+
+const (
+	// SeverityWarning does not affect parsing result.
+	SeverityWarning Severity = iota + 1
+	// SeverityError makes strict parsing fail with *ParseError,
+	// the offending line is skipped in lenient mode.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Diagnostic is a problem found in robots.txt content at specific position.
+// Entries of ParseError.Errs and RobotsData.Warnings are *Diagnostic,
+// use errors.As to inspect them.
+type Diagnostic struct {
+	Kind     DiagnosticKind
+	Severity Severity
+	Pos      token.Position
+	Msg      string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func newDiagnostic(kind DiagnosticKind, severity Severity, pos token.Position, err error) *Diagnostic {
+	return &Diagnostic{Kind: kind, Severity: severity, Pos: pos, Msg: err.Error(), Err: err}
+}
+
+func (d *Diagnostic) Error() string {
+	return d.Pos.String() + ": " + d.Msg
+}
+
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
@@ -0,0 +1,59 @@
+package robotstxt
+
+import (
+	"errors"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticPosition(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		input string
+		kind  DiagnosticKind
+		pos   token.Position
+		msg   string
+	}{
+		{"Disallow: /\nUser-agent: bot", KindRuleOutsideGroup,
+			token.Position{Filename: "bytes", Offset: 0, Line: 1, Column: 1}, "Disallow before User-agent"},
+		{"# comment\n  Allow: /", KindRuleOutsideGroup,
+			token.Position{Filename: "bytes", Offset: 12, Line: 2, Column: 3}, "Allow before User-agent"},
+		{"User-agent: bot\r\nCrawl-delay: bad-time-value", KindInvalidCrawlDelay,
+			token.Position{Filename: "bytes", Offset: 30, Line: 2, Column: 14}, "invalid syntax"},
+		{"User-agent: bot\n\nCrawl-delay: -inf", KindInvalidCrawlDelay,
+			token.Position{Filename: "bytes", Offset: 30, Line: 3, Column: 14}, "invalid value"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := FromString(c.input)
+			require.Error(t, err)
+			var d *Diagnostic
+			require.True(t, errors.As(err, &d), "expected *Diagnostic in %v", err)
+			assert.Equal(t, c.kind, d.Kind)
+			assert.Equal(t, SeverityError, d.Severity)
+			assert.Equal(t, c.pos, d.Pos)
+			assert.Contains(t, d.Msg, c.msg)
+			assert.Contains(t, err.Error(), c.pos.String()+": ")
+		})
+	}
+}
+
+func TestDiagnosticFilename(t *testing.T) {
+	t.Parallel()
+	r, err := Options{Lenient: true, Filename: "example.com/robots.txt"}.FromString("Disallow: /\n")
+	require.NoError(t, err)
+	require.Len(t, r.Warnings, 1)
+	assert.Equal(t, "example.com/robots.txt:1:1: Disallow before User-agent", r.Warnings[0].Error())
+}
+
+func TestDiagnosticUnwrap(t *testing.T) {
+	t.Parallel()
+	_, err := FromString("User-agent: bot\nCrawl-delay: x")
+	var num *strconv.NumError
+	require.True(t, errors.As(err, &num))
+	assert.Equal(t, strconv.ErrSyntax, num.Err)
+}
@@ -0,0 +1,90 @@
+package robotstxt
+
+import "strconv"
+
+// Decision tells what decided the result of Explain.
+type Decision int
+
+const (
+	// DecidedByRule means an Allow or Disallow rule matched the path.
+	DecidedByRule Decision = iota + 1
+	// DecidedByDefault means no rule matched the path, crawling is allowed
+	// by default.
+	DecidedByDefault
+	// DecidedByAllowAll means robots.txt has no rules at all.
+	DecidedByAllowAll
+	// DecidedByDisallowAll means everything is disallowed regardless of rules.
+	DecidedByDisallowAll
+	// DecidedByStatus means HTTP status of robots.txt response allowed or
+	// disallowed everything, see FromStatusAndBytes.
+	DecidedByStatus
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecidedByRule:
+		return "rule"
+	case DecidedByDefault:
+		return "default"
+	case DecidedByAllowAll:
+		return "allow-all"
+	case DecidedByDisallowAll:
+		return "disallow-all"
+	case DecidedByStatus:
+		return "status"
+	}
+	return "decision(" + strconv.Itoa(int(d)) + ")"
+}
+
+// Explanation describes why TestAgent returned Allowed.
+type Explanation struct {
+	Allowed  bool
+	Decision Decision
+	// Agent of the selected group, empty if no group applies.
+	Agent string
+	// Rule that decided the result, only set for DecidedByRule.
+	Rule *Rule
+	// Matches lists all rules of the group matching the path, in the order
+	// of robots.txt, including Rule.
+	Matches []Rule
+}
+
+// Explain returns the same result as TestAgent along with the reason.
+func (r *RobotsData) Explain(path, agent string) Explanation {
+	switch {
+	case r.fromStatus:
+		return Explanation{Allowed: r.allowAll, Decision: DecidedByStatus}
+	case r.allowAll:
+		return Explanation{Allowed: true, Decision: DecidedByAllowAll}
+	case r.disallowAll:
+		return Explanation{Allowed: false, Decision: DecidedByDisallowAll}
+	}
+	return r.FindGroup(agent).Explain(path)
+}
+
+// Explain returns the same result as Test along with the reason.
+func (g *Group) Explain(path string) Explanation {
+	if g.disallowAll {
+		return Explanation{Allowed: false, Decision: DecidedByDisallowAll}
+	}
+	path = normalizeURLPath(path)
+	e := Explanation{Agent: g.Agent}
+	ret, matched := g.matchRules(path, true)
+	if len(matched) > 0 {
+		e.Matches = make([]Rule, len(matched))
+		for i, m := range matched {
+			e.Matches[i] = m.export()
+			if m == ret {
+				e.Rule = &e.Matches[i]
+			}
+		}
+	}
+	if ret != nil {
+		e.Allowed = ret.allow
+		e.Decision = DecidedByRule
+	} else {
+		e.Allowed = true
+		e.Decision = DecidedByDefault
+	}
+	return e
+}
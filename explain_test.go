@@ -0,0 +1,100 @@
+package robotstxt
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainRule(t *testing.T) {
+	t.Parallel()
+	const input = `User-agent: *
+Disallow: /
+
+User-agent: bot
+Disallow: /private
+Allow: /private/ok
+Disallow: /private/*.gif
+`
+	r, err := FromString(input)
+	require.NoError(t, err)
+
+	e := r.Explain("/private/ok.gif", "Bot")
+	assert.False(t, e.Allowed)
+	assert.Equal(t, DecidedByRule, e.Decision)
+	assert.Equal(t, "bot", e.Agent)
+	require.NotNil(t, e.Rule)
+	assert.Equal(t, "/private/*.gif", e.Rule.Text)
+	assert.False(t, e.Rule.Allow)
+	assert.Equal(t, 7, e.Rule.Pos.Line)
+	require.Len(t, e.Matches, 3)
+	assert.Equal(t, "/private", e.Matches[0].Text)
+	assert.Equal(t, "/private/ok", e.Matches[1].Text)
+	assert.True(t, e.Matches[1].Allow)
+	assert.Equal(t, 6, e.Matches[1].Pos.Line)
+	assert.True(t, &e.Matches[2] == e.Rule)
+
+	e = r.Explain("/public", "bot")
+	assert.True(t, e.Allowed)
+	assert.Equal(t, DecidedByDefault, e.Decision)
+	assert.Equal(t, "bot", e.Agent)
+	assert.Nil(t, e.Rule)
+	assert.Empty(t, e.Matches)
+
+	e = r.Explain("/public", "otherbot")
+	assert.False(t, e.Allowed)
+	assert.Equal(t, "*", e.Agent)
+	assert.Equal(t, "/", e.Rule.Text)
+	assert.Equal(t, 2, e.Rule.Pos.Line)
+}
+
+func TestExplainShortcuts(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		allowed  bool
+		decision Decision
+	}{
+		{"empty", 200, "", true, DecidedByAllowAll},
+		{"no-groups", 200, "Sitemap: http://example.com/sitemap.xml", true, DecidedByDefault},
+		{"404", 404, "User-agent: *\nDisallow: /", true, DecidedByStatus},
+		{"503", 503, "", false, DecidedByStatus},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := FromStatusAndString(c.status, c.body)
+			require.NoError(t, err)
+			e := r.Explain("/", "bot")
+			assert.Equal(t, c.allowed, e.Allowed)
+			assert.Equal(t, c.decision, e.Decision)
+			assert.Nil(t, e.Rule)
+		})
+	}
+
+	e := (&RobotsData{disallowAll: true}).Explain("/", "bot")
+	assert.False(t, e.Allowed)
+	assert.Equal(t, DecidedByDisallowAll, e.Decision)
+}
+
+func TestExplainAgreesWithTestAgent(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{robotsText001, robotsGoogle, robotsCaseMatching, robotsCasePrecedence} {
+		for _, o := range []Options{{}, {RFC9309: true}} {
+			r, err := o.FromString(input)
+			require.NoError(t, err)
+			f := func(path, agent string) bool {
+				return r.Explain(path, agent).Allowed == r.TestAgent(path, agent)
+			}
+			require.NoError(t, quick.Check(f, nil))
+			for _, a := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "yandex"} {
+				for _, p := range []string{"/", "/fish.php", "/folder/page", "/page.htm", "/index.php?option=com_phorum,newer", "/news/directory"} {
+					assert.Equal(t, r.TestAgent(p, a), r.Explain(p, a).Allowed, "path=%s agent=%s", p, a)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package robotstxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// maxRedirects is the number of consecutive redirects Fetcher follows.
+// From RFC 9309:
+// Crawlers SHOULD follow at least five consecutive redirects, even across
+// authorities (for example, hosts in the case of HTTP).
+const maxRedirects = 5
+
+// Fetcher downloads and parses robots.txt. The zero value uses
+// http.DefaultClient and default Options.
+type Fetcher struct {
+	// Client makes requests, nil means http.DefaultClient. Its
+	// CheckRedirect, if set, is consulted for the redirects Fetcher follows.
+	Client *http.Client
+
+	// Options parse the response. Filename defaults to the final URL
+	// after redirects, Origin to the robots.txt URL.
+	Options Options
+}
+
+// RobotsURL returns the robots.txt URL for pageURL, an absolute URL of any
+// page of a site.
+func RobotsURL(pageURL string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("robotstxt: URL %q is not absolute", pageURL)
+	}
+	r := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: "/robots.txt"}
+	return r.String(), nil
+}
+
+// Fetch downloads robots.txt of the site of pageURL and parses it.
+// Statuses are handled as by FromStatusAndBytes, content past
+// Options.MaxSize is not read. Unavailable robots.txt, that is 4xx status
+// or more than five redirects, allows everything unless Options.StatusPolicy
+// has a rule for the status.
+//
+// Unreachable robots.txt, with a 5xx status, disallows everything. A network
+// error, including one while reading the body, is returned with nil data,
+// see Cache for mapping it to disallowing everything.
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) (*RobotsData, error) {
+	r, _, err := f.fetch(ctx, pageURL, nil)
+	return r, err
+}
+
+// Revalidate is Fetch that returns cached itself if it is still current.
+// The request is conditional on RobotsData.ETag and LastModified of cached,
+// the response 304 Not Modified means robots.txt did not change. Without
+// them, with nil cached or cached of another origin, it is Fetch.
+func (f *Fetcher) Revalidate(ctx context.Context, pageURL string, cached *RobotsData) (*RobotsData, error) {
+	r, _, err := f.fetch(ctx, pageURL, cached)
+	return r, err
+}
+
+// fetch is Revalidate that also returns the final response, nil if there
+// was none. Its body is closed.
+func (f *Fetcher) fetch(ctx context.Context, pageURL string, cached *RobotsData) (*RobotsData, *http.Response, error) {
+	robotsURL, err := RobotsURL(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	o := f.Options
+	if o.Origin == "" {
+		o.Origin = robotsURL
+	}
+	origin, err := o.origin()
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Validators of another site say nothing about this one.
+	conditional := cached != nil && cached.origin == origin && (cached.etag != "" || cached.lastModified != "")
+	if conditional {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	res, err := f.client().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if conditional && res.StatusCode == http.StatusNotModified {
+		return cached, res, nil
+	}
+	if _, ok := o.StatusPolicy.lookup(res.StatusCode); !ok && res.StatusCode >= 300 && res.StatusCode < 400 {
+		// From RFC 9309:
+		// If there are more than five consecutive redirects, crawlers MAY
+		// assume that the robots.txt file is unavailable.
+		r, err := o.shared(allowAll)
+		return r, res, err
+	}
+	r, err := o.FromResponse(res)
+	return r, res, err
+}
+
+// client returns Client with the redirect limit.
+func (f *Fetcher) client() *http.Client {
+	c := http.DefaultClient
+	if f.Client != nil {
+		c = f.Client
+	}
+	limited := *c
+	limited.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		if c.CheckRedirect != nil {
+			return c.CheckRedirect(req, via)
+		}
+		return nil
+	}
+	return &limited
+}
@@ -0,0 +1,243 @@
+package robotstxt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobotsURL(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"http://example.com":               "http://example.com/robots.txt",
+		"https://example.com:8443/a/b?x=1": "https://example.com:8443/robots.txt",
+		"http://user@example.com/#top":     "http://user@example.com/robots.txt",
+		"http://[::1]/page":                "http://[::1]/robots.txt",
+	}
+	for in, expect := range cases {
+		u, err := RobotsURL(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expect, u, in)
+	}
+	for _, in := range []string{"/page", "example.com/page", "http://%zz"} {
+		_, err := RobotsURL(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var f Fetcher
+	r, err := f.Fetch(context.Background(), server.URL+"/some/page?q=1")
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/private", "bot")
+	expectAccess(t, r, true, "/public", "bot")
+	assert.Equal(t, strings.ToLower(server.URL), r.Origin())
+
+	_, err = f.Fetch(context.Background(), "/relative")
+	assert.Error(t, err)
+}
+
+func TestFetchStatus(t *testing.T) {
+	t.Parallel()
+	var f Fetcher
+	for _, c := range []struct {
+		code  int
+		allow bool
+	}{
+		{200, false},
+		{401, true},
+		{403, true},
+		{404, true},
+		{500, false},
+		{503, false},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(c.code)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+		}))
+		r, err := f.Fetch(context.Background(), server.URL)
+		server.Close()
+		require.NoError(t, err, c.code)
+		expectAccess(t, r, c.allow, "/", "bot")
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	t.Parallel()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+	}))
+	defer target.Close()
+	// /robots.txt redirects n times via /n, /n-1 and so on.
+	var n int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		left := n
+		if req.URL.Path != "/robots.txt" {
+			left, _ = strconv.Atoi(req.URL.Path[1:])
+		}
+		if left <= 1 {
+			http.Redirect(w, req, target.URL+"/robots.txt", http.StatusMovedPermanently)
+			return
+		}
+		http.Redirect(w, req, "/"+strconv.Itoa(left-1), http.StatusFound)
+	}))
+	defer server.Close()
+
+	n = maxRedirects
+	f := Fetcher{Options: Options{Lenient: true}}
+	r, err := f.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", "bot")
+	// Rules apply to the initial authority.
+	assert.Equal(t, strings.ToLower(server.URL), r.Origin())
+	_, err = r.TestURLString(target.URL+"/", "bot")
+	assert.True(t, errors.Is(err, ErrOriginMismatch))
+
+	n = maxRedirects + 1
+	r, err = f.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/", "bot")
+	assert.Equal(t, strings.ToLower(server.URL), r.Origin())
+
+	// Redirect policy of the client is kept.
+	var redirects int
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		redirects++
+		return http.ErrUseLastResponse
+	}}
+	n = 1
+	r, err = (&Fetcher{Client: client}).Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/", "bot")
+	assert.Equal(t, 1, redirects)
+}
+
+func TestFetchMaxSize(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "User-agent: *\n")
+		line := "Disallow: /" + strings.Repeat("x", 100) + "\n"
+		for i := 0; i < 1000; i++ {
+			if _, err := fmt.Fprint(w, line); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	f := Fetcher{Options: Options{MaxSize: 1000}}
+	r, err := f.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.True(t, r.Truncated)
+	expectAccess(t, r, false, "/"+strings.Repeat("x", 100), "bot")
+}
+
+func TestFetchNetworkError(t *testing.T) {
+	t.Parallel()
+	cut := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Promise more content than sent.
+		w.Header().Set("Content-Length", "1000")
+		fmt.Fprint(w, "User-agent: *\nAllow: /\n")
+	}))
+	defer cut.Close()
+
+	var f Fetcher
+	r, err := f.Fetch(context.Background(), cut.URL)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	r, err = f.Fetch(context.Background(), url)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, err = f.Fetch(ctx, url)
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+	assert.Nil(t, r)
+}
+
+func TestFetchRevalidate(t *testing.T) {
+	t.Parallel()
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	var etag, ifNoneMatch, ifModifiedSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ifNoneMatch, ifModifiedSince = req.Header.Get("If-None-Match"), req.Header.Get("If-Modified-Since")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		if ifNoneMatch == etag || ifNoneMatch == "" && ifModifiedSince == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprintf(w, "User-agent: *\nDisallow: /%s\n", strings.Trim(etag, `"`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	var f Fetcher
+	etag = `"v1"`
+	r, err := f.Revalidate(ctx, server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", ifNoneMatch)
+	assert.Equal(t, `"v1"`, r.ETag())
+	assert.Equal(t, lastModified, r.LastModified())
+	expectAccess(t, r, false, "/v1", "bot")
+
+	r2, err := f.Revalidate(ctx, server.URL, r)
+	require.NoError(t, err)
+	assert.True(t, r == r2)
+	assert.Equal(t, `"v1"`, ifNoneMatch)
+	assert.Equal(t, lastModified, ifModifiedSince)
+
+	etag = `"v2"`
+	r2, err = f.Revalidate(ctx, server.URL, r)
+	require.NoError(t, err)
+	assert.True(t, r != r2)
+	assert.Equal(t, `"v2"`, r2.ETag())
+	expectAccess(t, r2, false, "/v2", "bot")
+
+	// Validators of another origin are not sent.
+	other := *r2
+	other.origin = "http://example.org"
+	r3, err := f.Revalidate(ctx, server.URL, &other)
+	require.NoError(t, err)
+	assert.True(t, r3 != &other)
+	assert.Equal(t, "", ifNoneMatch)
+	assert.Equal(t, "", ifModifiedSince)
+	assert.Equal(t, r2.Origin(), r3.Origin())
+
+	// Only Last-Modified.
+	r.etag = ""
+	r2, err = f.Revalidate(ctx, server.URL, r)
+	require.NoError(t, err)
+	assert.True(t, r == r2)
+	assert.Equal(t, "", ifNoneMatch)
+
+	// Without validators 304 is not expected.
+	r.lastModified = ""
+	etag = ""
+	r2, err = f.Revalidate(ctx, server.URL, r)
+	require.NoError(t, err)
+	assert.True(t, r != r2)
+	assert.Equal(t, "", ifModifiedSince)
+	expectAccess(t, r2, true, "/", "bot")
+}
@@ -25,5 +25,21 @@ func Fuzz(data []byte) int {
 		panic(err)
 	}
 
+	// Canonical text must parse into the same rules
+	r2, err := FromString(r.String())
+	if err != nil {
+		panic(err)
+	}
+	if r2.String() != r.String() {
+		panic("canonical text is not stable")
+	}
+	f3 := func(path, agent string) bool {
+		return r.TestAgent(path, agent) == r2.TestAgent(path, agent) &&
+			r.FindGroup(agent).CrawlDelay == r2.FindGroup(agent).CrawlDelay
+	}
+	if err := quick.Check(f3, nil); err != nil {
+		panic(err)
+	}
+
 	return 1
 }
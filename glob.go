@@ -0,0 +1,58 @@
+package robotstxt
+
+import "strings"
+
+// glob matches a path against an Allow or Disallow value with wildcards:
+// "*" designates 0 or more instances of any valid character, "$" designates
+// the end of the URL.
+//
+// Like plain rules, a glob matches from the start of path. "$" is special
+// only at the end of the value, elsewhere it is a literal character.
+type glob struct {
+	// parts are literals between "*", the first one must be a prefix of
+	// path, the following ones are found in order.
+	parts []string
+	// anchored is set by trailing "$": the last part must end path.
+	anchored bool
+	// n is the length of the value as written.
+	n int
+}
+
+// newGlob returns nil if value has no wildcards.
+func newGlob(value string) *glob {
+	if !strings.Contains(value, "*") && !strings.HasSuffix(value, "$") {
+		return nil
+	}
+	g := &glob{n: len(value)}
+	if strings.HasSuffix(value, "$") {
+		g.anchored = true
+		value = value[:len(value)-1]
+	}
+	g.parts = strings.Split(value, "*")
+	return g
+}
+
+// match reports whether path matches g. It takes linear time in practice
+// and does not allocate: every part is matched at its leftmost position,
+// which leaves the most of path to the following parts.
+func (g *glob) match(path string) bool {
+	first, last := g.parts[0], len(g.parts)-1
+	if !strings.HasPrefix(path, first) {
+		return false
+	}
+	if last == 0 {
+		return !g.anchored || len(path) == len(first)
+	}
+	path = path[len(first):]
+	for _, part := range g.parts[1:last] {
+		i := strings.Index(path, part)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(part):]
+	}
+	if g.anchored {
+		return strings.HasSuffix(path, g.parts[last])
+	}
+	return strings.Contains(path, g.parts[last])
+}
@@ -0,0 +1,164 @@
+package robotstxt
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"/fish*", []string{"/fish", "/fish.html", "/fishheads/yummy.html"}, []string{"/Fish.asp", "/catfish", "/desert/fish"}},
+		{"/*.php", []string{"/index.php", "/filename.php", "/folder/filename.php?parameters"}, []string{"/", "/windows.PHP"}},
+		{"/*.php$", []string{"/filename.php", "/folder/filename.php"}, []string{"/filename.php?parameters", "/filename.php/", "/windows.PHP"}},
+		{"/fish*.php", []string{"/fish.php", "/fishheads/catfish.php?parameters"}, []string{"/Fish.PHP", "/catfish.php"}},
+		{"/$", []string{"/"}, []string{"/a", ""}},
+		{"*/x/", []string{"/x/", "/a/x/b"}, []string{"/x", "/a/x"}},
+		{"/a**b$", []string{"/ab", "/a/b"}, []string{"/a/bc"}},
+		{"/ab*ba$", []string{"/abba", "/ab/ba", "/abaaba"}, []string{"/aba", "/ab", "/abab"}},
+		{"/a*a*a$", []string{"/aaa", "/a/a/a"}, []string{"/aa", "/aaab"}},
+	}
+	for _, c := range cases {
+		g := newGlob(c.pattern)
+		require.NotNil(t, g, c.pattern)
+		for _, p := range c.match {
+			assert.True(t, g.match(p), "%q must match %q", c.pattern, p)
+		}
+		for _, p := range c.noMatch {
+			assert.False(t, g.match(p), "%q must not match %q", c.pattern, p)
+		}
+	}
+	assert.Nil(t, newGlob("/plain/path"))
+	assert.Nil(t, newGlob("/a$b"), "$ is literal inside path")
+}
+
+// globRegexp is the reference implementation of glob.
+func globRegexp(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, `.*`)
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+func TestGlobRandom(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	str := func(alphabet string, n int) string {
+		b := make([]byte, rnd.Intn(n))
+		for i := range b {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	for i := 0; i < 20000; i++ {
+		pattern := "/" + str("ab*$", 6)
+		g := newGlob(pattern)
+		if g == nil {
+			continue
+		}
+		re := globRegexp(pattern)
+		for j := 0; j < 20; j++ {
+			path := "/" + str("ab$", 8)
+			require.Equal(t, re.MatchString(path), g.match(path), "pattern %q path %q", pattern, path)
+		}
+	}
+}
+
+func TestGlobAllocs(t *testing.T) {
+	g := newGlob("/a*b*c$")
+	allocs := testing.AllocsPerRun(100, func() { g.match("/aaa/bbb/ccc") })
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestWildcardPrecedence(t *testing.T) {
+	t.Parallel()
+	// Escaped regular expression of "/a.b*c" is longer than "/a.bcde", only
+	// the pattern as written is compared.
+	r, err := FromString("User-agent: *\nDisallow: /a.b*c\nAllow: /a.bcde\nDisallow: /x$y\n")
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/a.bcde", "bot")
+	expectAccess(t, r, false, "/x$yz", "bot")
+	expectAccess(t, r, false, "/a.bxc", "bot")
+}
+
+// robotsWildcards has hundreds of wildcard rules.
+var robotsWildcards = func() string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for i := 0; i < 300; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&b, "Disallow: /*/section%d/*.html$\n", i)
+		case 1:
+			fmt.Fprintf(&b, "Allow: /*?page=%d*\n", i)
+		case 2:
+			fmt.Fprintf(&b, "Disallow: /archive/%d/*/print$\n", i)
+		}
+	}
+	return b.String()
+}()
+
+var benchmarkWildcardPaths = []string{
+	"/news/section150/article.html",
+	"/news/section151/article.html",
+	"/search?page=100&q=go",
+	"/archive/299/2014/print",
+	"/archive/299/2014/print/",
+}
+
+func BenchmarkParseWildcards(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(robotsWildcards)))
+	for i := 0; i < b.N; i++ {
+		if _, err := FromString(robotsWildcards); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTestWildcards(b *testing.B) {
+	r, err := FromString(robotsWildcards)
+	if err != nil {
+		b.Fatal(err)
+	}
+	g := r.FindGroup("bot")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Test(benchmarkWildcardPaths[i%len(benchmarkWildcardPaths)])
+	}
+}
+
+// BenchmarkTestWildcardsRegexp is the baseline for BenchmarkTestWildcards,
+// rules compiled to regular expressions.
+func BenchmarkTestWildcardsRegexp(b *testing.B) {
+	r, err := FromString(robotsWildcards)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var res []*regexp.Regexp
+	for _, rule := range r.FindGroup("bot").rules {
+		res = append(res, globRegexp(rule.path))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		path := benchmarkWildcardPaths[i%len(benchmarkWildcardPaths)]
+		for _, re := range res {
+			re.MatchString(path)
+		}
+	}
+}
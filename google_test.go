@@ -56,10 +56,12 @@ Disallow: /`
 	agents := []string{"Googlebot-News (Googlebot)", "Googlebot", "Googlebot-Image (Googlebot)", "Otherbot (web)", "Otherbot (News)"}
 	paths := []string{"/1", "/3", "/3", "/2", "/2"}
 
-	r, err := FromString(robotsCaseOrder)
-	require.NoError(t, err)
-	for i, a := range agents {
-		expectAccess(t, r, false, paths[i], a)
+	for _, mode := range matchModes {
+		r, err := Options{MatchMode: mode}.FromString(robotsCaseOrder)
+		require.NoError(t, err)
+		for i, a := range agents {
+			expectAccess(t, r, false, paths[i], a)
+		}
 	}
 }
 
@@ -117,7 +119,7 @@ Disallow: /path*l$`
 
 	r, err := FromString(robotsCaseWildcards)
 	require.NoError(t, err)
-	assert.Equal(t, "/path.*l$", r.groups["*"].rules[0].pattern.String())
+	assert.Equal(t, &glob{parts: []string{"/path", "l"}, anchored: true, n: 8}, r.groups["*"].rules[0].pattern)
 }
 
 func TestURLMatching(t *testing.T) {
@@ -0,0 +1,47 @@
+package robotstxt
+
+import "strings"
+
+// AgentHierarchy maps a crawler product token to the token it falls back
+// to when robots.txt has no group for it. Keys and values are lower case.
+type AgentHierarchy map[string]string
+
+// Crawlers is the hierarchy of well known crawlers as documented by their
+// operators. It may be extended before use, it is not safe to modify
+// concurrently with Chain.
+var Crawlers = AgentHierarchy{
+	// https://developers.google.com/search/docs/crawling-indexing/overview-google-crawlers
+	"googlebot-image":       "googlebot",
+	"googlebot-news":        "googlebot",
+	"googlebot-video":       "googlebot",
+	"storebot-google":       "googlebot",
+	"google-inspectiontool": "googlebot",
+	// https://yandex.com/support/webmaster/controlling-robot/robots-txt.html
+	"yandexbot":       "yandex",
+	"yandeximages":    "yandex",
+	"yandexmetrika":   "yandex",
+	"yandexmobilebot": "yandex",
+}
+
+// Chain returns agent followed by the tokens it falls back to, in order,
+// for use with RobotsData.FindGroupChain:
+//
+//	robots.FindGroupChain(robotstxt.Crawlers.Chain("Googlebot-Image")...)
+func (h AgentHierarchy) Chain(agent string) []string {
+	chain := []string{agent}
+	key := strings.ToLower(agent)
+	for {
+		next, ok := h[key]
+		if !ok {
+			return chain
+		}
+		for _, a := range chain {
+			if strings.EqualFold(a, next) {
+				// Cycle in the hierarchy.
+				return chain
+			}
+		}
+		chain = append(chain, next)
+		key = next
+	}
+}
@@ -0,0 +1,54 @@
+package robotstxt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentHierarchyChain(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"Googlebot-Image", "googlebot"}, Crawlers.Chain("Googlebot-Image"))
+	assert.Equal(t, []string{"Googlebot"}, Crawlers.Chain("Googlebot"))
+	assert.Equal(t, []string{"foobot"}, Crawlers.Chain("foobot"))
+
+	h := AgentHierarchy{"a": "b", "b": "c", "c": "a"}
+	assert.Equal(t, []string{"a", "b", "c"}, h.Chain("a"))
+}
+
+func TestFindGroupChain(t *testing.T) {
+	t.Parallel()
+	const robots = `User-agent: googlebot
+Disallow: /google
+
+User-agent: googlebot-news
+Disallow: /news
+
+User-agent: *
+Disallow: /all
+`
+	for _, o := range []Options{{}, {RFC9309: true}} {
+		r, err := o.FromString(robots)
+		require.NoError(t, err)
+		cases := map[string]string{
+			"Googlebot-News":  "googlebot-news",
+			"Googlebot-Image": "googlebot",
+			"Googlebot":       "googlebot",
+			"Storebot-Google": "googlebot",
+			"YandexBot":       "*",
+			"":                "*",
+		}
+		for agent, expect := range cases {
+			assert.Equal(t, expect, r.FindGroupChain(Crawlers.Chain(agent)...).Agent, agent)
+		}
+		assert.Equal(t, "*", r.FindGroupChain().Agent)
+		assert.Equal(t, "googlebot", r.FindGroupChain("otherbot", "Googlebot").Agent)
+	}
+
+	// Without "*" group the chain ends with an empty group.
+	r, err := FromString("User-agent: yandex\nDisallow: /\n")
+	require.NoError(t, err)
+	assert.False(t, r.FindGroupChain(Crawlers.Chain("YandexImages")...).Test("/a"))
+	assert.True(t, r.FindGroupChain(Crawlers.Chain("Googlebot")...).Test("/a"))
+}
@@ -0,0 +1,308 @@
+package robotstxt
+
+// JSON schema, version 1.
+//
+// RobotsData:
+//
+//	{
+//	  "version": 1,
+//	  "allowAll": false,       // optional, everything allowed
+//	  "disallowAll": false,    // optional, everything disallowed
+//	  "fromStatus": false,     // optional, allowAll/disallowAll come from HTTP status
+//	  "truncated": false,      // optional, see RobotsData.Truncated
+//	  "rfc9309": false,        // optional, parsed in RFC 9309 mode
+//	  "matchMode": "google",   // optional, MatchMode if not default for rfc9309
+//	  "precedence": "google",  // optional, Precedence if not default for rfc9309
+//	  "fullUserAgent": false,  // optional, see Options.FullUserAgent
+//	  "filename": "bytes",     // optional, source name of rule positions
+//	  "origin": "https://example.com", // optional, see Options.Origin
+//	  "etag": "\"v1\"",        // optional, see RobotsData.ETag
+//	  "lastModified": "Mon, 01 Jan 2024 00:00:00 GMT", // optional, see RobotsData.LastModified
+//	  "host": "example.com",   // optional
+//	  "sitemaps": ["https://example.com/sitemap.xml"], // optional
+//	  "groups": [Group, ...]   // optional, sorted by agent
+//	}
+//
+// Group, standalone Group has also "version", "precedence" and "filename":
+//
+//	{
+//	  "agent": "googlebot",    // lower case
+//	  "crawlDelay": 2.5,       // optional, seconds
+//	  "rules": [Rule, ...]     // optional, in robots.txt order
+//	}
+//
+// Rule:
+//
+//	{
+//	  "path": "/private*.gif$", // normalized path, see Rule.Path
+//	  "allow": false,           // optional
+//	  "wildcard": true,         // optional, path is a pattern with "*" or "$"
+//	  "text": "private*.gif$",  // optional, path as written in robots.txt
+//	  "offset": 42,             // optional, position of the directive
+//	  "line": 3,                // optional
+//	  "column": 1               // optional
+//	}
+//
+// Unknown fields are ignored, unsupported version is an error.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jsonVersion = 1
+
+type jsonRobots struct {
+	Version       int          `json:"version"`
+	AllowAll      bool         `json:"allowAll,omitempty"`
+	DisallowAll   bool         `json:"disallowAll,omitempty"`
+	FromStatus    bool         `json:"fromStatus,omitempty"`
+	Truncated     bool         `json:"truncated,omitempty"`
+	RFC9309       bool         `json:"rfc9309,omitempty"`
+	MatchMode     string       `json:"matchMode,omitempty"`
+	Precedence    string       `json:"precedence,omitempty"`
+	FullUserAgent bool         `json:"fullUserAgent,omitempty"`
+	Filename      string       `json:"filename,omitempty"`
+	Origin        string       `json:"origin,omitempty"`
+	ETag          string       `json:"etag,omitempty"`
+	LastModified  string       `json:"lastModified,omitempty"`
+	Host          string       `json:"host,omitempty"`
+	Sitemaps      []string     `json:"sitemaps,omitempty"`
+	Groups        []*jsonGroup `json:"groups,omitempty"`
+}
+
+type jsonGroup struct {
+	Version    int         `json:"version,omitempty"`
+	Precedence string      `json:"precedence,omitempty"`
+	Filename   string      `json:"filename,omitempty"`
+	Agent      string      `json:"agent"`
+	CrawlDelay json.Number `json:"crawlDelay,omitempty"`
+	Rules      []jsonRule  `json:"rules,omitempty"`
+}
+
+type jsonRule struct {
+	Path     string `json:"path"`
+	Allow    bool   `json:"allow,omitempty"`
+	Wildcard bool   `json:"wildcard,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// MarshalJSON encodes r in versioned schema described in json.go.
+func (r *RobotsData) MarshalJSON() ([]byte, error) {
+	j := jsonRobots{
+		Version:       jsonVersion,
+		AllowAll:      r.allowAll,
+		DisallowAll:   r.disallowAll,
+		FromStatus:    r.fromStatus,
+		Truncated:     r.Truncated,
+		RFC9309:       r.rfc9309,
+		FullUserAgent: r.fullUserAgent,
+		Origin:        r.origin,
+		ETag:          r.etag,
+		LastModified:  r.lastModified,
+		Host:          r.Host,
+		Sitemaps:      r.Sitemaps,
+	}
+	if r.match != MatchDefault && r.match != MatchDefault.resolve(r.rfc9309) {
+		j.MatchMode = r.match.String()
+	}
+	j.Precedence = precedenceJSON(r.precedence, r.rfc9309)
+	for _, a := range r.Agents() {
+		g := r.groups[a]
+		j.Groups = append(j.Groups, g.toJSON())
+		if j.Filename == "" {
+			j.Filename = g.filename()
+		}
+	}
+	return json.Marshal(&j)
+}
+
+// UnmarshalJSON decodes r from versioned schema described in json.go
+// and rebuilds patterns of wildcard rules.
+func (r *RobotsData) UnmarshalJSON(data []byte) error {
+	var j jsonRobots
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != jsonVersion {
+		return fmt.Errorf("robotstxt: unsupported JSON version %d", j.Version)
+	}
+	match := MatchDefault.resolve(j.RFC9309)
+	if j.MatchMode != "" {
+		var ok bool
+		if match, ok = parseMatchMode(j.MatchMode); !ok || match == MatchDefault {
+			return fmt.Errorf("robotstxt: unknown match mode %q", j.MatchMode)
+		}
+	}
+	precedence, err := precedenceFromJSON(j.Precedence, j.RFC9309)
+	if err != nil {
+		return err
+	}
+	origin := j.Origin
+	if origin != "" {
+		if origin, err = parseOrigin(origin); err != nil {
+			return err
+		}
+	}
+	groups := make(map[string]*Group, len(j.Groups))
+	for _, jg := range j.Groups {
+		g, err := jg.toGroup(j.Filename)
+		if err != nil {
+			return err
+		}
+		if _, ok := groups[g.Agent]; ok {
+			return fmt.Errorf("robotstxt: duplicate group %q", g.Agent)
+		}
+		groups[g.Agent] = g
+	}
+	*r = RobotsData{
+		Host:          j.Host,
+		Sitemaps:      j.Sitemaps,
+		Truncated:     j.Truncated,
+		allowAll:      j.AllowAll,
+		disallowAll:   j.DisallowAll,
+		fromStatus:    j.FromStatus,
+		rfc9309:       j.RFC9309,
+		match:         match,
+		precedence:    precedence,
+		fullUserAgent: j.FullUserAgent,
+		origin:        origin,
+		etag:          j.ETag,
+		lastModified:  j.LastModified,
+		groups:        groups,
+	}
+	setPrecedence(groups, precedence)
+	r.indexAgents()
+	return nil
+}
+
+// MarshalJSON encodes g in versioned schema described in json.go.
+func (g *Group) MarshalJSON() ([]byte, error) {
+	j := g.toJSON()
+	j.Version = jsonVersion
+	j.Precedence = precedenceJSON(g.precedence, false)
+	j.Filename = g.filename()
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes g from versioned schema described in json.go
+// and rebuilds patterns of wildcard rules.
+func (g *Group) UnmarshalJSON(data []byte) error {
+	var j jsonGroup
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != jsonVersion {
+		return fmt.Errorf("robotstxt: unsupported JSON version %d", j.Version)
+	}
+	precedence, err := precedenceFromJSON(j.Precedence, false)
+	if err != nil {
+		return err
+	}
+	decoded, err := j.toGroup(j.Filename)
+	if err != nil {
+		return err
+	}
+	setPrecedence(map[string]*Group{decoded.Agent: decoded}, precedence)
+	*g = *decoded
+	return nil
+}
+
+// precedenceJSON returns "precedence" value, empty if p is the default.
+func precedenceJSON(p Precedence, rfc9309 bool) string {
+	if p == PrecedenceDefault || p == PrecedenceDefault.resolve(rfc9309) {
+		return ""
+	}
+	return p.String()
+}
+
+// precedenceFromJSON is the reverse of precedenceJSON.
+func precedenceFromJSON(s string, rfc9309 bool) (Precedence, error) {
+	if s == "" {
+		return PrecedenceDefault.resolve(rfc9309), nil
+	}
+	p, ok := parsePrecedence(s)
+	if !ok || p == PrecedenceDefault {
+		return 0, fmt.Errorf("robotstxt: unknown precedence %q", s)
+	}
+	return p, nil
+}
+
+func (g *Group) toJSON() *jsonGroup {
+	j := &jsonGroup{Agent: g.Agent}
+	if g.CrawlDelay != 0 {
+		j.CrawlDelay = json.Number(formatDelay(g.CrawlDelay))
+	}
+	for _, r := range g.rules {
+		j.Rules = append(j.Rules, jsonRule{
+			Path:     r.path,
+			Allow:    r.allow,
+			Wildcard: r.pattern != nil,
+			Text:     r.text,
+			Offset:   r.pos.Offset,
+			Line:     r.pos.Line,
+			Column:   r.pos.Column,
+		})
+	}
+	return j
+}
+
+func (j *jsonGroup) toGroup(filename string) (*Group, error) {
+	if j.Agent == "" {
+		return nil, errors.New("robotstxt: group without agent")
+	}
+	g := &Group{Agent: strings.ToLower(j.Agent)}
+	if j.CrawlDelay != "" {
+		cd, err := strconv.ParseFloat(string(j.CrawlDelay), 64)
+		if err == nil {
+			err = checkCrawlDelay(cd, string(j.CrawlDelay))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("robotstxt: group %q: %w", j.Agent, err)
+		}
+		g.CrawlDelay = time.Duration(math.Round(cd * float64(time.Second)))
+	}
+	g.rules = make([]*rule, len(j.Rules))
+	for i, jr := range j.Rules {
+		r, err := jr.toRule()
+		if err != nil {
+			return nil, fmt.Errorf("robotstxt: group %q: %w", j.Agent, err)
+		}
+		r.pos.Filename = filename
+		g.rules[i] = r
+	}
+	return g, nil
+}
+
+func (j *jsonRule) toRule() (*rule, error) {
+	if j.Path == "" {
+		return nil, errors.New("rule without path")
+	}
+	path, pattern := parsePath(j.Path)
+	if path != j.Path {
+		return nil, fmt.Errorf("rule path %q must start with \"/\" or \"*\", not end with \"*\" and have normalized percent-encoding, expected %q", j.Path, path)
+	}
+	if j.Wildcard != (pattern != nil) {
+		return nil, fmt.Errorf("rule path %q wildcard=%t does not match content", j.Path, j.Wildcard)
+	}
+	r := &rule{path: path, allow: j.Allow, pattern: pattern, text: j.Text}
+	r.pos.Offset, r.pos.Line, r.pos.Column = j.Offset, j.Line, j.Column
+	return r, nil
+}
+
+func (g *Group) filename() string {
+	for _, r := range g.rules {
+		if r.pos.Filename != "" {
+			return r.pos.Filename
+		}
+	}
+	return ""
+}
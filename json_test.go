@@ -0,0 +1,141 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+	const input = `User-agent: FooBot
+Crawl-delay: 2.5
+Disallow: private*.gif$
+Allow: /private/
+Sitemap: https://example.com/sitemap.xml
+Host: example.com
+`
+	r, err := FromString(input)
+	require.NoError(t, err)
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": 1,
+		"filename": "bytes",
+		"host": "example.com",
+		"sitemaps": ["https://example.com/sitemap.xml"],
+		"groups": [{
+			"agent": "foobot",
+			"crawlDelay": 2.5,
+			"rules": [
+				{"path": "/private*.gif$", "wildcard": true, "text": "private*.gif$", "offset": 36, "line": 3, "column": 1},
+				{"path": "/private/", "allow": true, "text": "/private/", "offset": 60, "line": 4, "column": 1}
+			]
+		}]
+	}`, string(data))
+
+	var decoded RobotsData
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, r.String(), decoded.String())
+	assert.Equal(t, r.FindGroup("foobot").Rules(), decoded.FindGroup("foobot").Rules())
+	assert.Equal(t, 2500*time.Millisecond, decoded.FindGroup("foobot").CrawlDelay)
+	expectAccess(t, &decoded, false, "/private/a.gif", "foobot")
+	expectAccess(t, &decoded, true, "/private/a.gifx", "foobot")
+	expectAccess(t, &decoded, true, "/private/a.png", "foobot")
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	inputs := []string{robotsText001, robotsGoogle, robotsCaseMatching, robotsCasePrecedence, robotsTextVanityfair}
+	for _, o := range []Options{{}, {RFC9309: true}} {
+		for _, input := range inputs {
+			r, err := o.FromString(input)
+			require.NoError(t, err)
+			data, err := json.Marshal(r)
+			require.NoError(t, err)
+			var decoded RobotsData
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			again, err := json.Marshal(&decoded)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(data), string(again))
+			f := func(path, agent string) bool {
+				return r.TestAgent(path, agent) == decoded.TestAgent(path, agent)
+			}
+			require.NoError(t, quick.Check(f, nil))
+			for _, a := range r.Agents() {
+				for _, rule := range r.FindGroup(a).Rules() {
+					assert.Equal(t, r.TestAgent(rule.Path, a), decoded.TestAgent(rule.Path, a))
+				}
+			}
+		}
+	}
+}
+
+func TestJSONFullUserAgent(t *testing.T) {
+	t.Parallel()
+	const header = "Mozilla/5.0 (compatible; Googlebot/2.1)"
+	r, err := Options{FullUserAgent: true}.FromString("User-agent: googlebot\nDisallow: /\n")
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", header)
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"fullUserAgent":true`)
+	var decoded RobotsData
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	expectAccess(t, &decoded, false, "/", header)
+}
+
+func TestJSONShortcuts(t *testing.T) {
+	t.Parallel()
+	for _, r := range []*RobotsData{allowAll, disallowAll, emptyRobots} {
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		var decoded RobotsData
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, r.Explain("/", "bot"), decoded.Explain("/", "bot"), string(data))
+	}
+	data, err := json.Marshal(disallowAll)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"disallowAll":true,"fromStatus":true}`, string(data))
+}
+
+func TestJSONGroup(t *testing.T) {
+	t.Parallel()
+	r, err := Options{RFC9309: true}.FromString("User-agent: a\nDisallow: /caf%c3%a9\n")
+	require.NoError(t, err)
+	data, err := json.Marshal(r.FindGroup("a"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"precedence":"rfc9309","filename":"bytes","agent":"a",
+		"rules":[{"path":"/caf%C3%A9","text":"/caf%c3%a9","offset":14,"line":2,"column":1}]}`, string(data))
+
+	var g Group
+	require.NoError(t, json.Unmarshal(data, &g))
+	assert.False(t, g.Test("/café"))
+	assert.Equal(t, r.FindGroup("a").Rules(), g.Rules())
+}
+
+func TestJSONErrors(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		`{}`:                          "unsupported JSON version 0",
+		`{"version":2}`:               "unsupported JSON version 2",
+		`{"version":1,"groups":[{}]}`: "group without agent",
+		`{"version":1,"groups":[{"agent":"a"},{"agent":"A"}]}`:                           `duplicate group "a"`,
+		`{"version":1,"groups":[{"agent":"a","crawlDelay":-1}]}`:                         "Crawl-delay invalid value '-1'",
+		`{"version":1,"groups":[{"agent":"a","rules":[{}]}]}`:                            "rule without path",
+		`{"version":1,"groups":[{"agent":"a","rules":[{"path":"x*"}]}]}`:                 `rule path "x*" must start with "/" or "*", not end with "*"`,
+		`{"version":1,"groups":[{"agent":"a","rules":[{"path":"/%7e"}]}]}`:               `normalized percent-encoding, expected "/~"`,
+		`{"version":1,"groups":[{"agent":"a","rules":[{"path":"/x$"}]}]}`:                `wildcard=false does not match`,
+		`{"version":1,"groups":[{"agent":"a","rules":[{"path":"/x","wildcard":true}]}]}`: `wildcard=true does not match`,
+	}
+	for input, expect := range cases {
+		var r RobotsData
+		err := json.Unmarshal([]byte(input), &r)
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), expect)
+	}
+}
@@ -0,0 +1,107 @@
+package robotstxt
+
+import (
+	"sort"
+	"strconv"
+)
+
+// MatchMode selects how FindGroup and TestAgent match a crawler agent to
+// User-agent lines of robots.txt. Matching is case-insensitive in every mode,
+// "*" group is used when no other group matches.
+type MatchMode int
+
+const (
+	// MatchDefault is MatchExactToken with Options.RFC9309, MatchPrefix
+	// otherwise.
+	MatchDefault MatchMode = iota
+	// MatchPrefix selects the group with the longest agent that is a prefix
+	// of the crawler agent, so "google" captures "googlebot-news".
+	MatchPrefix
+	// MatchExactToken selects the group with agent equal to the product
+	// token of the crawler agent, e.g. "googlebot" for "Googlebot/2.1",
+	// as RFC 9309 specifies.
+	MatchExactToken
+	// MatchGoogle is MatchExactToken as implemented by Google's robots.txt
+	// parser: User-agent values are reduced to their product token too, so
+	// "Googlebot/2.1" line applies to "googlebot", and all groups with the
+	// same token are merged.
+	MatchGoogle
+)
+
+var matchModeNames = [...]string{
+	MatchDefault:    "default",
+	MatchPrefix:     "prefix",
+	MatchExactToken: "exact-token",
+	MatchGoogle:     "google",
+}
+
+func (m MatchMode) String() string {
+	if m >= 0 && int(m) < len(matchModeNames) {
+		return matchModeNames[m]
+	}
+	return "match-mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// parseMatchMode is the reverse of MatchMode.String.
+func parseMatchMode(s string) (MatchMode, bool) {
+	for m, name := range matchModeNames {
+		if name == s {
+			return MatchMode(m), true
+		}
+	}
+	return 0, false
+}
+
+// resolve returns the effective mode, never MatchDefault.
+func (m MatchMode) resolve(rfc9309 bool) MatchMode {
+	if m != MatchDefault {
+		return m
+	}
+	if rfc9309 {
+		return MatchExactToken
+	}
+	return MatchPrefix
+}
+
+// indexAgents prepares lookup of groups for r.match.
+func (r *RobotsData) indexAgents() {
+	r.agents, r.tokens = nil, nil
+	switch r.match {
+	case MatchPrefix:
+		r.agents = newAgentIndex(r.groups)
+	case MatchGoogle:
+		r.tokens = newTokenIndex(r.groups)
+	}
+}
+
+// newTokenIndex maps product tokens of group agents to groups, merging
+// groups with the same token. Merged rules keep robots.txt order, crawl
+// delay comes from the group that is exactly the token if set.
+func newTokenIndex(groups map[string]*Group) map[string]*Group {
+	byToken := make(map[string][]*Group)
+	for a, g := range groups {
+		if token := leadingToken(a); token != "" {
+			byToken[token] = append(byToken[token], g)
+		}
+	}
+	tokens := make(map[string]*Group, len(byToken))
+	for token, gs := range byToken {
+		if len(gs) == 1 {
+			tokens[token] = gs[0]
+			continue
+		}
+		sort.Slice(gs, func(i, j int) bool { return gs[i].Agent < gs[j].Agent })
+		merged := &Group{Agent: token, precedence: gs[0].precedence}
+		for _, g := range gs {
+			merged.rules = append(merged.rules, g.rules...)
+			if g.CrawlDelay != 0 && (merged.CrawlDelay == 0 || g.Agent == token) {
+				merged.CrawlDelay = g.CrawlDelay
+			}
+		}
+		sort.SliceStable(merged.rules, func(i, j int) bool {
+			return merged.rules[i].pos.Offset < merged.rules[j].pos.Offset
+		})
+		tokens[token] = merged
+	}
+	return tokens
+}
@@ -0,0 +1,108 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var matchModes = []MatchMode{MatchDefault, MatchPrefix, MatchExactToken, MatchGoogle}
+
+func TestMatchMode(t *testing.T) {
+	t.Parallel()
+	const robots = `User-agent: google
+Disallow: /google
+
+User-agent: bot
+Disallow: /bot
+
+User-agent: Googlebot/2.1
+Disallow: /googlebot-2.1
+
+User-agent: googlebot
+Disallow: /googlebot
+Crawl-delay: 2
+
+User-agent: *
+Disallow: /all
+`
+	cases := []struct {
+		agent  string
+		expect map[MatchMode]string
+	}{
+		{"Googlebot-News", map[MatchMode]string{MatchPrefix: "googlebot", MatchExactToken: "*", MatchGoogle: "*"}},
+		{"Googlebot", map[MatchMode]string{MatchPrefix: "googlebot", MatchExactToken: "googlebot", MatchGoogle: "googlebot"}},
+		{"Googlebot/2.1", map[MatchMode]string{MatchPrefix: "googlebot/2.1", MatchExactToken: "googlebot", MatchGoogle: "googlebot"}},
+		{"Google", map[MatchMode]string{MatchPrefix: "google", MatchExactToken: "google", MatchGoogle: "google"}},
+		{"botify", map[MatchMode]string{MatchPrefix: "bot", MatchExactToken: "*", MatchGoogle: "*"}},
+		{"Bot/1.0", map[MatchMode]string{MatchPrefix: "bot", MatchExactToken: "bot", MatchGoogle: "bot"}},
+		{"", map[MatchMode]string{MatchPrefix: "*", MatchExactToken: "*", MatchGoogle: "*"}},
+	}
+	for mode := MatchPrefix; mode <= MatchGoogle; mode++ {
+		r, err := Options{MatchMode: mode}.FromString(robots)
+		require.NoError(t, err)
+		for _, c := range cases {
+			assert.Equal(t, c.expect[mode], r.FindGroup(c.agent).Agent, "mode %s agent %q", mode, c.agent)
+		}
+	}
+
+	// Google mode merges "googlebot" and "googlebot/2.1" groups.
+	r, err := Options{MatchMode: MatchGoogle}.FromString(robots)
+	require.NoError(t, err)
+	g := r.FindGroup("googlebot")
+	assert.False(t, g.Test("/googlebot-2.1"))
+	assert.False(t, g.Test("/googlebot"))
+	assert.Equal(t, "/googlebot-2.1", g.Rules()[0].Path, "rules keep robots.txt order")
+	assert.Equal(t, 2*time.Second, g.CrawlDelay)
+	assert.Equal(t, "googlebot", r.Explain("/googlebot", "Googlebot/2.1").Agent)
+
+	// Default follows RFC9309.
+	r, err = FromString(robots)
+	require.NoError(t, err)
+	assert.Equal(t, "googlebot", r.FindGroup("googlebot-news").Agent)
+	r, err = Options{RFC9309: true}.FromString(robots)
+	require.NoError(t, err)
+	assert.Equal(t, "*", r.FindGroup("googlebot-news").Agent)
+	r, err = Options{RFC9309: true, MatchMode: MatchPrefix}.FromString(robots)
+	require.NoError(t, err)
+	assert.Equal(t, "googlebot", r.FindGroup("googlebot-news").Agent)
+}
+
+func TestMatchModeEncoding(t *testing.T) {
+	t.Parallel()
+	const robots = "User-agent: googlebot/2.1\nDisallow: /a\n"
+	for _, o := range []Options{{}, {MatchMode: MatchGoogle}, {MatchMode: MatchExactToken}, {RFC9309: true, MatchMode: MatchPrefix}} {
+		r, err := o.FromString(robots)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		var fromJSON RobotsData
+		require.NoError(t, json.Unmarshal(data, &fromJSON))
+		assert.Equal(t, r.match, fromJSON.match, string(data))
+
+		data, err = r.MarshalBinary()
+		require.NoError(t, err)
+		var fromBinary RobotsData
+		require.NoError(t, fromBinary.UnmarshalBinary(data))
+		assert.Equal(t, r.match, fromBinary.match)
+
+		for _, a := range []string{"googlebot", "googlebot/2.1", "googlebot-news"} {
+			assert.Equal(t, r.FindGroup(a).Agent, fromJSON.FindGroup(a).Agent)
+			assert.Equal(t, r.FindGroup(a).Agent, fromBinary.FindGroup(a).Agent)
+		}
+	}
+
+	var r RobotsData
+	assert.EqualError(t, json.Unmarshal([]byte(`{"version":1,"matchMode":"suffix"}`), &r), `robotstxt: unknown match mode "suffix"`)
+	assert.EqualError(t, r.UnmarshalBinary([]byte("RTXT\x01\x80\x10")), "robotstxt: unknown binary flags 0x800")
+}
+
+func TestMatchModeString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "exact-token", MatchExactToken.String())
+	assert.Equal(t, "match-mode(9)", MatchMode(9).String())
+}
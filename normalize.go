@@ -0,0 +1,96 @@
+package robotstxt
+
+// From RFC 9309:
+// Octets in the URI and robots.txt paths outside the range of the ASCII coded
+// character set, and those in the reserved range defined by [RFC3986], MUST
+// be percent-encoded as defined by [RFC3986] prior to comparison.
+//
+// If a percent-encoded ASCII octet is encountered in the URI, it MUST be
+// unencoded prior to comparison, unless it is a reserved character in the
+// URI as defined by [RFC3986] or the character is outside the unreserved
+// character range.
+
+import "strings"
+
+const upperHex = "0123456789ABCDEF"
+
+// normalizePath brings a robots.txt rule path into canonical form:
+// escapes of unreserved characters are decoded, other escapes get upper-case
+// hex digits, octets outside of printable US-ASCII and stray '%' are
+// percent-encoded. Wildcard characters keep their special meaning, '$' other
+// than the last one is not special and gets encoded as in a tested path.
+func normalizePath(p string) string {
+	return normalize(p, false)
+}
+
+// normalizeURLPath is normalizePath for a path being tested against rules.
+// Here '*' and '$' are ordinary characters, they are percent-encoded so that
+// only an explicitly encoded rule like "/a%2A" matches them.
+func normalizeURLPath(p string) string {
+	return normalize(p, true)
+}
+
+func normalize(p string, escapeSpecial bool) string {
+	i := 0
+	for ; i < len(p); i++ {
+		if needsEscape(p[i], escapeSpecial) || p[i] == '%' || p[i] == '$' && i < len(p)-1 {
+			break
+		}
+	}
+	if i == len(p) {
+		// fast path, nothing to do
+		return p
+	}
+
+	var b strings.Builder
+	b.Grow(len(p) + 8)
+	b.WriteString(p[:i])
+	for ; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '%' && i+2 < len(p) && isHex(p[i+1]) && isHex(p[i+2]):
+			v := unhex(p[i+1])<<4 | unhex(p[i+2])
+			if isUnreserved(v) {
+				b.WriteByte(v)
+			} else {
+				writeEscape(&b, v)
+			}
+			i += 2
+		case c == '%' || needsEscape(c, escapeSpecial) || c == '$' && i < len(p)-1:
+			writeEscape(&b, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func needsEscape(c byte, escapeSpecial bool) bool {
+	return c <= ' ' || c >= 0x7f || (escapeSpecial && (c == '*' || c == '$'))
+}
+
+func writeEscape(b *strings.Builder, c byte) {
+	b.WriteByte('%')
+	b.WriteByte(upperHex[c>>4])
+	b.WriteByte(upperHex[c&15])
+}
+
+// isUnreserved reports whether c is in the RFC 3986 unreserved set.
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
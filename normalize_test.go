@@ -0,0 +1,63 @@
+package robotstxt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePath(t *testing.T) {
+	t.Parallel()
+	cases := []struct{ in, rule, url string }{
+		{"/plain/path", "/plain/path", "/plain/path"},
+		{"/%7euser", "/~user", "/~user"},
+		{"/%7Euser/%41", "/~user/A", "/~user/A"},
+		{"/caf%c3%a9", "/caf%C3%A9", "/caf%C3%A9"},
+		{"/café", "/caf%C3%A9", "/caf%C3%A9"},
+		{"/a%2fb", "/a%2Fb", "/a%2Fb"},
+		{"/a%3Fb", "/a%3Fb", "/a%3Fb"},
+		{"/a b", "/a%20b", "/a%20b"},
+		{"/100%", "/100%25", "/100%25"},
+		{"/%zz", "/%25zz", "/%25zz"},
+		{"/a*b$", "/a*b$", "/a%2Ab%24"},
+		{"/a$b", "/a%24b", "/a%24b"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.rule, normalizePath(c.in), "rule %q", c.in)
+		assert.Equal(t, c.url, normalizeURLPath(c.in), "url %q", c.in)
+	}
+}
+
+func TestPercentEncoding(t *testing.T) {
+	t.Parallel()
+	const robots = `User-agent: *
+Disallow: /caf%C3%A9
+Disallow: /%7Euser
+Disallow: /x%2Fy
+Disallow: /Straße
+Disallow: /a$b
+`
+	cases := []struct {
+		path  string
+		allow bool
+	}{
+		{"/café", false},
+		{"/caf%c3%a9", false},
+		{"/cafe", true},
+		{"/~user/page", false},
+		{"/%7euser", false},
+		{"/x%2fy", false},
+		{"/x/y", true},
+		{"/Stra%C3%9Fe", false},
+		{"/a$b", false},
+		{"/a%24b", false},
+	}
+	for _, o := range []Options{{}, {RFC9309: true}, {Compile: true}} {
+		r, err := o.FromString(robots)
+		require.NoError(t, err)
+		for _, c := range cases {
+			expectAccess(t, r, c.allow, c.path, "bot")
+		}
+	}
+}
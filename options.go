@@ -0,0 +1,335 @@
+package robotstxt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/token"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// rfc9309MaxSize is the amount of content RFC 9309 requires crawlers to
+// parse, anything past it may be ignored. It is the default MaxSize.
+const rfc9309MaxSize = 500 * 1024
+
+// Options controls how robots.txt content is parsed and matched.
+// The zero value gives the same results as package level functions
+// FromBytes, FromResponse and friends.
+type Options struct {
+	// RFC9309 switches the whole pipeline to RFC 9309 semantics:
+	//   - user-agents are matched by product token, not by prefix
+	//   - an Allow rule wins over a Disallow rule of equal length
+	RFC9309 bool
+
+	// Lenient makes parsing return the rules it could understand instead of
+	// nil data when some lines are invalid. Problems are reported in
+	// RobotsData.Warnings. By default any problem is a *ParseError.
+	// Lines are cut at 16664 bytes, as by Google's parser. An Allow or
+	// Disallow path cut this way is ignored in both modes, as its prefix
+	// would match more paths than written, and reported in Warnings.
+	Lenient bool
+
+	// MaxSize limits robots.txt content in bytes, 500 KiB when zero, no
+	// limit when negative. Lines past the limit are ignored, including the
+	// one crossing it, and RobotsData.Truncated is set. FromResponse reads
+	// at most that much of the body.
+	MaxSize int
+
+	// Filename is reported in positions of diagnostics. FromResponse uses
+	// request URL when empty, otherwise it defaults to "bytes".
+	Filename string
+
+	// Compile builds an index of rules for fast matching of many paths,
+	// see RobotsData.Compile.
+	Compile bool
+
+	// MatchMode selects how agents are matched to groups, see MatchMode.
+	MatchMode MatchMode
+
+	// Precedence selects which of matching rules decides, see Precedence.
+	Precedence Precedence
+
+	// Origin is the URL robots.txt was fetched from, or any URL of the same
+	// scheme, host and port. RobotsData.TestURL rejects URLs of other
+	// origins. FromResponse uses URL of the first request when empty.
+	Origin string
+
+	// StatusPolicy maps HTTP statuses of FromStatusAndBytes, FromResponse
+	// and Fetcher to outcomes, DefaultStatusPolicy when nil.
+	StatusPolicy StatusPolicy
+
+	// FullUserAgent makes FindGroup, TestAgent and Explain accept whole
+	// User-Agent header values, groups are matched by ProductToken of it.
+	FullUserAgent bool
+
+	// AgentCacheSize is the number of user-agent strings for which
+	// FindGroup remembers the result, the least recently used ones are
+	// dropped first. The cache is safe for concurrent use. Zero disables it.
+	AgentCacheSize int
+
+	// Logger, if set, receives every diagnostic found while parsing:
+	// warnings at slog.LevelWarn and errors at slog.LevelError.
+	Logger *slog.Logger
+}
+
+// FromStatusAndBytes returns robots data for HTTP statusCode and body,
+// as Options.StatusPolicy maps the status.
+func (o Options) FromStatusAndBytes(statusCode int, body []byte) (*RobotsData, error) {
+	outcome := o.StatusPolicy.Outcome(statusCode)
+	if outcome == StatusParse {
+		return o.FromBytes(body)
+	}
+	return o.fromStatus(statusCode, outcome)
+}
+
+func (o Options) FromStatusAndString(statusCode int, body string) (*RobotsData, error) {
+	return o.FromStatusAndBytes(statusCode, []byte(body))
+}
+
+func (o Options) FromResponse(res *http.Response) (*RobotsData, error) {
+	if res == nil {
+		// Edge case, if res is nil, return nil data
+		return nil, nil
+	}
+	if o.Filename == "" && res.Request != nil && res.Request.URL != nil {
+		o.Filename = res.Request.URL.String()
+	}
+	if o.Origin == "" && res.Request != nil {
+		// From RFC 9309:
+		// If a robots.txt file is reached within five consecutive redirects,
+		// the robots.txt file MUST be fetched, parsed, and its rules followed
+		// in the context of the initial authority.
+		req := res.Request
+		for req.Response != nil && req.Response.Request != nil {
+			req = req.Response.Request
+		}
+		if req.URL != nil {
+			o.Origin = req.URL.String()
+		}
+	}
+	var r *RobotsData
+	var err error
+	outcome := o.StatusPolicy.Outcome(res.StatusCode)
+	if outcome == StatusParse {
+		r, err = o.FromReader(res.Body)
+	} else {
+		// Body of other statuses is not used.
+		r, err = o.fromStatus(res.StatusCode, outcome)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withValidators(r, res.Header), nil
+}
+
+// withValidators returns r with ETag and Last-Modified of header h, a copy
+// if r is a shared instance.
+func withValidators(r *RobotsData, h http.Header) *RobotsData {
+	etag, lastModified := h.Get("ETag"), h.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return r
+	}
+	if r == allowAll || r == disallowAll || r == emptyRobots {
+		c := *r
+		r = &c
+	}
+	r.etag, r.lastModified = etag, lastModified
+	return r
+}
+
+func (o Options) FromBytes(body []byte) (r *RobotsData, err error) {
+	origin, err := o.origin()
+	if err != nil {
+		return nil, err
+	}
+
+	// From RFC 9309:
+	// Crawlers MUST be able to parse at least 500 kibibytes (KiB).
+	var truncated *Diagnostic
+	if n := o.maxSize(); n >= 0 && len(body) > n {
+		body = truncateBody(body, n)
+		truncated = newTruncatedDiagnostic(endPosition(o.filename(), body))
+	}
+
+	// special case (probably not worth optimization?)
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 && truncated == nil {
+		return o.shared(emptyRobots)
+	}
+
+	sc := newByteScanner(o.filename())
+	sc.feed(body, true)
+	tokens := sc.scanAll()
+
+	// special case worth optimization
+	if len(tokens) == 0 && len(sc.Diagnostics) == 0 && truncated == nil {
+		return o.shared(emptyRobots)
+	}
+
+	parser := newParser(tokens)
+	parser.rfc9309 = o.RFC9309
+	parser.parseTokens(true)
+	return o.build(origin, parser, sc.Diagnostics, truncated)
+}
+
+// build returns RobotsData of everything parser got, scanned are
+// diagnostics of the scanner.
+func (o Options) build(origin string, parser *parser, scanned []*Diagnostic, truncated *Diagnostic) (*RobotsData, error) {
+	var errs []error
+	r := &RobotsData{rfc9309: o.RFC9309, origin: origin, Truncated: truncated != nil}
+	r.groups, r.Host, r.Sitemaps, errs = parser.finish()
+	diags := mergeDiagnostics(scanned, errs)
+	if truncated != nil {
+		diags = append(diags, truncated)
+	}
+	o.logDiagnostics(diags)
+	if hasErrors(errs) && !o.Lenient {
+		return nil, newParseError(diags)
+	}
+	r.Warnings = diags
+	o.index(r)
+
+	return r, nil
+}
+
+// index prepares groups of freshly built r for queries.
+func (o Options) index(r *RobotsData) {
+	r.precedence = o.Precedence.resolve(o.RFC9309)
+	setPrecedence(r.groups, r.precedence)
+	r.match = o.MatchMode.resolve(o.RFC9309)
+	r.indexAgents()
+	if o.Compile {
+		r.Compile()
+	}
+	r.fullUserAgent = o.FullUserAgent
+	if o.AgentCacheSize > 0 {
+		r.cache = newAgentCache(o.AgentCacheSize)
+	}
+}
+
+func (o Options) maxSize() int {
+	if o.MaxSize == 0 {
+		return rfc9309MaxSize
+	}
+	return o.MaxSize
+}
+
+func (o Options) filename() string {
+	if o.Filename == "" {
+		return "bytes"
+	}
+	return o.Filename
+}
+
+// origin returns normalized Options.Origin, empty if not set.
+func (o Options) origin() (string, error) {
+	if o.Origin == "" {
+		return "", nil
+	}
+	return parseOrigin(o.Origin)
+}
+
+// shared returns r, one of shared instances, or its copy with Options.Origin.
+func (o Options) shared(r *RobotsData) (*RobotsData, error) {
+	origin, err := o.origin()
+	if err != nil || origin == "" {
+		return r, err
+	}
+	c := *r
+	c.origin = origin
+	return &c, nil
+}
+
+func (o Options) FromString(body string) (r *RobotsData, err error) {
+	return o.FromBytes([]byte(body))
+}
+
+func (o Options) logDiagnostics(diags []error) {
+	if o.Logger == nil {
+		return
+	}
+	for _, e := range diags {
+		var d *Diagnostic
+		if !errors.As(e, &d) {
+			continue
+		}
+		level := slog.LevelWarn
+		if d.Severity == SeverityError {
+			level = slog.LevelError
+		}
+		o.Logger.LogAttrs(context.Background(), level, d.Msg,
+			slog.String("kind", d.Kind.String()),
+			slog.String("pos", d.Pos.String()))
+	}
+}
+
+// hasErrors tells whether errs fail strict parsing, see Severity.
+func hasErrors(errs []error) bool {
+	for _, e := range errs {
+		var d *Diagnostic
+		if !errors.As(e, &d) || d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeDiagnostics combines scanner and parser diagnostics ordered by offset.
+func mergeDiagnostics(scanned []*Diagnostic, parsed []error) []error {
+	if len(scanned) == 0 {
+		return parsed
+	}
+	all := make([]error, 0, len(scanned)+len(parsed))
+	for _, d := range scanned {
+		all = append(all, d)
+	}
+	all = append(all, parsed...)
+	slices.SortStableFunc(all, func(a, b error) int {
+		return diagnosticOffset(a) - diagnosticOffset(b)
+	})
+	return all
+}
+
+func diagnosticOffset(err error) int {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		return d.Pos.Offset
+	}
+	return 0
+}
+
+// truncateBody cuts body to at most n bytes. A line crossing the limit
+// is dropped as a whole, so a rule is never shortened into another one.
+func truncateBody(body []byte, n int) []byte {
+	if len(body) <= n {
+		return body
+	}
+	if n > 0 && body[n-1] == '\r' && body[n] == '\n' {
+		// "\r\n" is a single line break.
+		n--
+	}
+	body = body[:n]
+	return body[:bytes.LastIndexAny(body, "\r\n")+1]
+}
+
+// endPosition returns the position right after content.
+func endPosition(filename string, content []byte) token.Position {
+	pos := token.Position{Filename: filename, Offset: len(content), Line: 1, Column: 1}
+	for i, c := range content {
+		// Same line breaks as the scanner counts: "\n", "\r\n" and "\r".
+		if c == '\n' || c == '\r' && (i+1 == len(content) || content[i+1] != '\n') {
+			pos.Line++
+		}
+	}
+	return pos
+}
+
+// newTruncatedDiagnostic reports content dropped after pos, the start
+// of a line.
+func newTruncatedDiagnostic(pos token.Position) *Diagnostic {
+	return newDiagnostic(KindTruncated, SeverityWarning, pos,
+		errors.New("content past "+strconv.Itoa(pos.Offset)+" bytes is ignored"))
+}
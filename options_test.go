@@ -0,0 +1,232 @@
+package robotstxt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLenient(t *testing.T) {
+	t.Parallel()
+	const input = `Disallow: /orphan
+User-agent: bot
+Disallow: /private
+Crawl-delay: abc
+Allow: /private/ok
+Crawl-delay: -inf
+`
+	_, err := FromString(input)
+	require.Error(t, err)
+
+	r, err := Options{Lenient: true}.FromString(input)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	expectAccess(t, r, false, "/private", "bot")
+	expectAccess(t, r, true, "/private/ok", "bot")
+	expectAccess(t, r, true, "/orphan", "bot")
+	require.Len(t, r.Warnings, 3)
+	assert.Contains(t, r.Warnings[0].Error(), "Disallow before User-agent")
+	assert.Contains(t, r.Warnings[1].Error(), "invalid syntax")
+	assert.Contains(t, r.Warnings[2].Error(), "invalid value")
+}
+
+func TestLenientClean(t *testing.T) {
+	t.Parallel()
+	r, err := Options{Lenient: true}.FromString(robotsText001)
+	require.NoError(t, err)
+	assert.Empty(t, r.Warnings)
+}
+
+func TestScannerWarnings(t *testing.T) {
+	t.Parallel()
+	const input = "User-agent: bot\nDisallow: /\xff\n"
+
+	r, err := FromString(input)
+	require.NoError(t, err)
+	require.Len(t, r.Warnings, 1)
+	var d *Diagnostic
+	require.True(t, errors.As(r.Warnings[0], &d))
+	assert.Equal(t, KindInvalidUTF8, d.Kind)
+	assert.Equal(t, 2, d.Pos.Line)
+
+	// Strict mode reports warnings along with errors, ordered by position.
+	_, err = FromString("Allow: /\n" + input)
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	require.Len(t, pe.Errs, 2)
+	assert.Contains(t, pe.Errs[0].Error(), "bytes:1:1: Allow before User-agent")
+	assert.Contains(t, pe.Errs[1].Error(), "bytes:3:12: illegal UTF-8 encoding")
+}
+
+func TestLongRule(t *testing.T) {
+	t.Parallel()
+	long := "/private" + strings.Repeat("x", maxLineLength)
+	input := "User-agent: bot\nDisallow: " + long + "\nDisallow: /other\n"
+
+	// Strict parsing keeps the other rules, the rule is ignored rather
+	// than matched as the prefix that fits the line.
+	for _, o := range []Options{{}, {Lenient: true}} {
+		r, err := o.FromString(input)
+		require.NoError(t, err)
+		require.Len(t, r.Warnings, 2)
+		var d *Diagnostic
+		require.True(t, errors.As(r.Warnings[0], &d))
+		assert.Equal(t, KindRuleTooLong, d.Kind)
+		assert.Equal(t, SeverityWarning, d.Severity)
+		assert.Equal(t, 2, d.Pos.Line)
+		assert.Equal(t, 11, d.Pos.Column)
+		expectAccess(t, r, true, "/private", "bot")
+		expectAccess(t, r, true, long[:maxLineLength-len("Disallow: ")], "bot")
+		expectAccess(t, r, false, "/other", "bot")
+	}
+
+	// Only trailing spaces are cut.
+	r, err := FromString("User-agent: bot\nDisallow: /private" + strings.Repeat(" ", maxLineLength))
+	require.NoError(t, err)
+	require.Len(t, r.Warnings, 1)
+	expectAccess(t, r, false, "/private", "bot")
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	_, err := Options{Lenient: true, Logger: logger}.FromString("Allow: /\nUser-agent: *\nDisallow: /\x00\n")
+	require.NoError(t, err)
+	assert.Equal(t, `level=ERROR msg="Allow before User-agent" kind=rule-outside-group pos=bytes:1:1
+level=WARN msg="NUL byte" kind=nul-byte pos=bytes:3:12
+`, buf.String())
+}
+
+func TestMaxSize(t *testing.T) {
+	t.Parallel()
+	const input = "User-agent: *\r\nDisallow: /a\r\nDisallow: /b\r\n"
+	cases := []struct {
+		max       int
+		truncated bool
+		line      int
+		b         bool
+	}{
+		{len(input), false, 0, false},
+		{len(input) - 1, true, 3, true},
+		{len(input) - 2, true, 3, true},
+		{17, true, 2, true},
+		{5, true, 1, true},
+		{-1, false, 0, false},
+	}
+	for _, c := range cases {
+		r, err := Options{MaxSize: c.max}.FromString(input)
+		require.NoError(t, err, "max %d", c.max)
+		assert.Equal(t, c.truncated, r.Truncated, "max %d", c.max)
+		expectAccess(t, r, c.b, "/b", "bot")
+		if !c.truncated {
+			assert.Empty(t, r.Warnings)
+			continue
+		}
+		require.Len(t, r.Warnings, 1)
+		var d *Diagnostic
+		require.True(t, errors.As(r.Warnings[0], &d))
+		assert.Equal(t, KindTruncated, d.Kind)
+		assert.Equal(t, SeverityWarning, d.Severity)
+		assert.Equal(t, c.line, d.Pos.Line, "max %d", c.max)
+	}
+
+	// Nothing left is still a valid robots.txt, distinct from empty one.
+	r, err := Options{MaxSize: 5}.FromString(input)
+	require.NoError(t, err)
+	assert.True(t, r != emptyRobots)
+	expectAccess(t, r, true, "/a", "bot")
+}
+
+// endlessReader yields robots.txt lines forever.
+type endlessReader struct{ n int }
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	const line = "Disallow: /x\n"
+	for i := range p {
+		p[i] = line[r.n%len(line)]
+		r.n++
+	}
+	return len(p), nil
+}
+
+func TestMaxSizeResponse(t *testing.T) {
+	t.Parallel()
+	body := &endlessReader{}
+	res := newHttpResponse(200, "")
+	res.Body = ioutil.NopCloser(io.MultiReader(strings.NewReader("User-agent: *\n"), body))
+	r, err := Options{MaxSize: 1000}.FromResponse(res)
+	require.NoError(t, err)
+	assert.True(t, r.Truncated)
+	assert.Equal(t, 1001-len("User-agent: *\n"), body.n)
+	expectAccess(t, r, false, "/x", "bot")
+
+	res = newHttpResponse(200, "User-agent: *\nDisallow: /x\n")
+	r, err = FromResponse(res)
+	require.NoError(t, err)
+	assert.False(t, r.Truncated)
+}
+
+func TestResponseValidators(t *testing.T) {
+	t.Parallel()
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	res := newHttpResponse(200, "User-agent: *\nDisallow: /x\n")
+	res.Header.Set("ETag", `"v1"`)
+	res.Header.Set("Last-Modified", lastModified)
+	r, err := FromResponse(res)
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, r.ETag())
+	assert.Equal(t, lastModified, r.LastModified())
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	var fromJSON RobotsData
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, `"v1"`, fromJSON.ETag())
+	assert.Equal(t, lastModified, fromJSON.LastModified())
+
+	data, err = r.MarshalBinary()
+	require.NoError(t, err)
+	var fromBinary RobotsData
+	require.NoError(t, fromBinary.UnmarshalBinary(data))
+	assert.Equal(t, `"v1"`, fromBinary.ETag())
+	assert.Equal(t, lastModified, fromBinary.LastModified())
+
+	// Shared instances are copied.
+	for _, c := range []struct {
+		status int
+		body   string
+		shared *RobotsData
+	}{
+		{404, "", allowAll},
+		{503, "", disallowAll},
+		{200, "# comment", emptyRobots},
+	} {
+		res := newHttpResponse(c.status, c.body)
+		res.Header.Set("ETag", `"v2"`)
+		r, err := FromResponse(res)
+		require.NoError(t, err)
+		assert.True(t, r != c.shared, "status %d modified a shared instance", c.status)
+		assert.Equal(t, `"v2"`, r.ETag())
+		assert.Equal(t, "", c.shared.ETag())
+
+		r, err = FromResponse(newHttpResponse(c.status, c.body))
+		require.NoError(t, err)
+		assert.True(t, r == c.shared)
+	}
+}
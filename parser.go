@@ -7,10 +7,11 @@ package robotstxt
 // http://en.wikipedia.org/wiki/Robots.txt
 
 import (
+	"errors"
 	"fmt"
+	"go/token"
 	"io"
 	"math"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -30,20 +31,36 @@ const (
 )
 
 type parser struct {
-	tokens []string
-	pos    int
+	tokens  []scanToken
+	pos     int
+	rfc9309 bool
+
+	// State between feeds of tokens.
+	groups       map[string]*Group
+	agents       []string
+	isEmptyGroup bool
+	host         string
+	sitemaps     []string
+	errs         []error
 }
 
 type lineInfo struct {
-	t  lineType       // Type of line key
-	k  string         // String representation of the type of key
-	vs string         // String value of the key
-	vf float64        // Float value of the key
-	vr *regexp.Regexp // Regexp value of the key
+	t   lineType       // Type of line key
+	k   string         // String representation of the type of key
+	vs  string         // String value of the key
+	vf  float64        // Float value of the key
+	vg  *glob          // Wildcard pattern value of the key
+	v   string         // Value as written
+	pos token.Position // Position of the key
 }
 
-func newParser(tokens []string) *parser {
-	return &parser{tokens: tokens}
+func newParser(tokens []scanToken) *parser {
+	return &parser{
+		tokens:       tokens,
+		groups:       make(map[string]*Group, 16),
+		agents:       make([]string, 0, 4),
+		isEmptyGroup: true,
+	}
 }
 
 func parseGroupMap(groups map[string]*Group, agents []string, fun func(*Group)) {
@@ -59,140 +76,151 @@ func parseGroupMap(groups map[string]*Group, agents []string, fun func(*Group))
 }
 
 func (p *parser) parseAll() (groups map[string]*Group, host string, sitemaps []string, errs []error) {
-	groups = make(map[string]*Group, 16)
-	agents := make([]string, 0, 4)
-	isEmptyGroup := true
+	p.parseTokens(true)
+	return p.finish()
+}
 
-	// Reset internal fields, tokens are assigned at creation time, never change
+// feed parses tokens following the ones parsed before. A line cut by the end
+// of tokens is completed by the next feed, end tells there is none.
+func (p *parser) feed(tokens []scanToken, end bool) {
+	p.tokens = append(p.tokens[:0], p.tokens[p.pos:]...)
+	p.tokens = append(p.tokens, tokens...)
 	p.pos = 0
+	p.parseTokens(end)
+}
 
-	for {
+func (p *parser) parseTokens(end bool) {
+	// parseLine looks at two tokens, the rest of the line is consumed
+	// by following calls.
+	for end || len(p.tokens)-p.pos >= 2 {
 		if li, err := p.parseLine(); err != nil {
 			if err == io.EOF {
 				break
 			}
-			errs = append(errs, err)
+			p.errs = append(p.errs, err)
 		} else {
-			switch li.t {
-			case lUserAgent:
-				// Two successive user-agent lines are part of the same group.
-				if !isEmptyGroup {
-					// End previous group
-					agents = make([]string, 0, 4)
-				}
-				if len(agents) == 0 {
-					isEmptyGroup = true
-				}
-				agents = append(agents, li.vs)
-
-			case lDisallow:
-				// Error if no current group
-				if len(agents) == 0 {
-					errs = append(errs, fmt.Errorf("Disallow before User-agent at token #%d", p.pos))
-				} else {
-					isEmptyGroup = false
-					var r *rule
-					if li.vr != nil {
-						r = &rule{"", false, li.vr}
-					} else {
-						r = &rule{li.vs, false, nil}
-					}
-					parseGroupMap(groups, agents, func(g *Group) { g.rules = append(g.rules, r) })
-				}
-
-			case lAllow:
-				// Error if no current group
-				if len(agents) == 0 {
-					errs = append(errs, fmt.Errorf("Allow before User-agent at token #%d", p.pos))
-				} else {
-					isEmptyGroup = false
-					var r *rule
-					if li.vr != nil {
-						r = &rule{"", true, li.vr}
-					} else {
-						r = &rule{li.vs, true, nil}
-					}
-					parseGroupMap(groups, agents, func(g *Group) { g.rules = append(g.rules, r) })
-				}
-
-			case lHost:
-				host = li.vs
-
-			case lSitemap:
-				sitemaps = append(sitemaps, li.vs)
-
-			case lCrawlDelay:
-				if len(agents) == 0 {
-					errs = append(errs, fmt.Errorf("Crawl-delay before User-agent at token #%d", p.pos))
-				} else {
-					isEmptyGroup = false
-					delay := time.Duration(li.vf * float64(time.Second))
-					parseGroupMap(groups, agents, func(g *Group) { g.CrawlDelay = delay })
-				}
-			}
+			p.apply(li)
 		}
 	}
-	return
+}
+
+// apply adds a parsed line to the state.
+func (p *parser) apply(li *lineInfo) {
+	switch li.t {
+	case lUserAgent:
+		// Two successive user-agent lines are part of the same group.
+		if !p.isEmptyGroup {
+			// End previous group
+			p.agents = make([]string, 0, 4)
+		}
+		if len(p.agents) == 0 {
+			p.isEmptyGroup = true
+		}
+		p.agents = append(p.agents, li.vs)
+
+	case lDisallow:
+		// Error if no current group
+		if len(p.agents) == 0 {
+			p.errs = append(p.errs, newDiagnostic(KindRuleOutsideGroup, SeverityError, li.pos, errors.New("Disallow before User-agent")))
+		} else if li.vs == "" {
+			// Empty value is not a rule, but still belongs to the group
+			p.isEmptyGroup = false
+			parseGroupMap(p.groups, p.agents, func(*Group) {})
+		} else {
+			p.isEmptyGroup = false
+			r := &rule{path: li.vs, allow: false, pattern: li.vg, text: li.v, pos: li.pos}
+			parseGroupMap(p.groups, p.agents, func(g *Group) { g.rules = append(g.rules, r) })
+		}
+
+	case lAllow:
+		// Error if no current group
+		if len(p.agents) == 0 {
+			p.errs = append(p.errs, newDiagnostic(KindRuleOutsideGroup, SeverityError, li.pos, errors.New("Allow before User-agent")))
+		} else if li.vs == "" {
+			// Empty value is not a rule, but still belongs to the group
+			p.isEmptyGroup = false
+			parseGroupMap(p.groups, p.agents, func(*Group) {})
+		} else {
+			p.isEmptyGroup = false
+			r := &rule{path: li.vs, allow: true, pattern: li.vg, text: li.v, pos: li.pos}
+			parseGroupMap(p.groups, p.agents, func(g *Group) { g.rules = append(g.rules, r) })
+		}
+
+	case lHost:
+		p.host = li.vs
+
+	case lSitemap:
+		p.sitemaps = append(p.sitemaps, li.vs)
+
+	case lCrawlDelay:
+		if len(p.agents) == 0 {
+			p.errs = append(p.errs, newDiagnostic(KindRuleOutsideGroup, SeverityError, li.pos, errors.New("Crawl-delay before User-agent")))
+		} else {
+			p.isEmptyGroup = false
+			delay := time.Duration(li.vf * float64(time.Second))
+			parseGroupMap(p.groups, p.agents, func(g *Group) { g.CrawlDelay = delay })
+		}
+	}
+}
+
+// finish returns the result of all fed tokens.
+func (p *parser) finish() (groups map[string]*Group, host string, sitemaps []string, errs []error) {
+	if p.rfc9309 && p.isEmptyGroup && len(p.agents) > 0 {
+		// Trailing user-agent lines without rules still form a group of
+		// their own. From RFC 9309 example 5.1: "quxbot" has an empty group
+		// and may crawl everything instead of falling back to "*".
+		parseGroupMap(p.groups, p.agents, func(*Group) {})
+	}
+	return p.groups, p.host, p.sitemaps, p.errs
 }
 
 func (p *parser) parseLine() (li *lineInfo, err error) {
-	t1, ok1 := p.popToken()
+	tok1, ok1 := p.popToken()
 	if !ok1 {
 		// proper EOF
 		return nil, io.EOF
 	}
 
-	t2, ok2 := p.peekToken()
+	tok2, ok2 := p.peekToken()
 	if !ok2 {
 		// EOF, no value associated with the token, so ignore token and return
 		return nil, io.EOF
 	}
+	t1, t2 := tok1.text, tok2.text
 
 	// Helper closure for all string-based tokens, common behaviour:
+	// - If empty (end of line), return unknown line info
 	// - Consume t2 token
-	// - If empty, return unknown line info
 	// - Otherwise return the specified line info
 	returnStringVal := func(t lineType) (*lineInfo, error) {
+		if t2 == tokEOL {
+			return &lineInfo{t: lIgnore}, nil
+		}
 		p.popToken()
 		if t2 != "" {
-			return &lineInfo{t: t, k: t1, vs: t2}, nil
+			return &lineInfo{t: t, k: t1, vs: t2, pos: tok1.pos}, nil
 		}
 		return &lineInfo{t: lIgnore}, nil
 	}
 
 	// Helper closure for all path tokens (allow/disallow), common behaviour:
+	// - If empty (end of line), return line info without path
 	// - Consume t2 token
-	// - If empty, return unknown line info
-	// - Otherwise, normalize the path (add leading "/" if missing, remove trailing "*")
-	// - Detect if wildcards are present, if so, compile into a regexp
+	// - Ignore the line with a warning if t2 was cut by maxLineLength
+	// - Otherwise parse the path, see parsePath
 	// - Return the specified line info
 	returnPathVal := func(t lineType) (*lineInfo, error) {
+		if t2 == tokEOL {
+			return &lineInfo{t: t, k: t1, pos: tok1.pos}, nil
+		}
 		p.popToken()
+		if tok2.truncated {
+			// A prefix of the path would match more than the rule says.
+			return nil, newDiagnostic(KindRuleTooLong, SeverityWarning, tok2.pos, fmt.Errorf("%s path longer than %d bytes", t1, maxLineLength))
+		}
 		if t2 != "" {
-			if !strings.HasPrefix(t2, "*") && !strings.HasPrefix(t2, "/") {
-				t2 = "/" + t2
-			}
-			t2 = strings.TrimRightFunc(t2, isAsterisk)
-			// From google's spec:
-			// Google, Bing, Yahoo, and Ask support a limited form of
-			// "wildcards" for path values. These are:
-			//   * designates 0 or more instances of any valid character
-			//   $ designates the end of the URL
-			if strings.ContainsAny(t2, "*$") {
-				// Must compile a regexp, this is a pattern.
-				// Escape string before compile.
-				t2 = regexp.QuoteMeta(t2)
-				t2 = strings.ReplaceAll(t2, `\*`, `.*`)
-				t2 = strings.ReplaceAll(t2, `\$`, `$`)
-				if r, e := regexp.Compile(t2); e != nil {
-					return nil, e
-				} else {
-					return &lineInfo{t: t, k: t1, vr: r}, nil
-				}
-			} else {
-				// Simple string path
-				return &lineInfo{t: t, k: t1, vs: t2}, nil
-			}
+			path, g := parsePath(t2)
+			return &lineInfo{t: t, k: t1, vs: path, vg: g, v: t2, pos: tok1.pos}, nil
 		}
 		return &lineInfo{t: lIgnore}, nil
 	}
@@ -238,11 +266,11 @@ func (p *parser) parseLine() (li *lineInfo, err error) {
 		// number of seconds to wait between successive requests to the same server.
 		p.popToken()
 		if cd, e := strconv.ParseFloat(t2, 64); e != nil {
-			return nil, e
-		} else if cd < 0 || math.IsInf(cd, 0) || math.IsNaN(cd) {
-			return nil, fmt.Errorf("Crawl-delay invalid value '%s'", t2)
+			return nil, newDiagnostic(KindInvalidCrawlDelay, SeverityError, tok2.pos, e)
+		} else if e = checkCrawlDelay(cd, t2); e != nil {
+			return nil, newDiagnostic(KindInvalidCrawlDelay, SeverityError, tok2.pos, e)
 		} else {
-			return &lineInfo{t: lCrawlDelay, k: t1, vf: cd}, nil
+			return &lineInfo{t: lCrawlDelay, k: t1, vf: cd, pos: tok1.pos}, nil
 		}
 	}
 
@@ -251,7 +279,34 @@ func (p *parser) parseLine() (li *lineInfo, err error) {
 	return &lineInfo{t: lUnknown, k: t1}, nil
 }
 
-func (p *parser) popToken() (tok string, ok bool) {
+// parsePath normalizes an Allow or Disallow value: adds leading "/" if
+// missing, removes trailing "*" and normalizes percent-encoding, see
+// normalizePath. Values with wildcards get a glob pattern.
+func parsePath(value string) (path string, pattern *glob) {
+	path = value
+	if !strings.HasPrefix(path, "*") && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	path = strings.TrimRightFunc(path, isAsterisk)
+	path = normalizePath(path)
+	// From google's spec:
+	// Google, Bing, Yahoo, and Ask support a limited form of
+	// "wildcards" for path values. These are:
+	//   * designates 0 or more instances of any valid character
+	//   $ designates the end of the URL
+	return path, newGlob(path)
+}
+
+// checkCrawlDelay validates Crawl-delay value in seconds, text is the value
+// as written.
+func checkCrawlDelay(cd float64, text string) error {
+	if cd < 0 || math.IsInf(cd, 0) || math.IsNaN(cd) {
+		return fmt.Errorf("Crawl-delay invalid value '%s'", text)
+	}
+	return nil
+}
+
+func (p *parser) popToken() (tok scanToken, ok bool) {
 	tok, ok = p.peekToken()
 	if !ok {
 		return
@@ -260,9 +315,9 @@ func (p *parser) popToken() (tok string, ok bool) {
 	return tok, true
 }
 
-func (p *parser) peekToken() (tok string, ok bool) {
+func (p *parser) peekToken() (tok scanToken, ok bool) {
 	if p.pos >= len(p.tokens) {
-		return "", false
+		return scanToken{}, false
 	}
 	return p.tokens[p.pos], true
 }
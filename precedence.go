@@ -0,0 +1,130 @@
+package robotstxt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Precedence selects which of the Allow and Disallow rules matching a path
+// decides the result. Rule length is the length of the path as written,
+// wildcards included.
+type Precedence int
+
+const (
+	// PrecedenceDefault is PrecedenceRFC9309 with Options.RFC9309,
+	// PrecedenceLongest otherwise.
+	PrecedenceDefault Precedence = iota
+	// PrecedenceLongest picks the longest matching rule, the earlier one
+	// on a tie. This is the historical behaviour of this package.
+	PrecedenceLongest
+	// PrecedenceRFC9309 picks the longest matching rule, Allow on a tie
+	// with Disallow, as RFC 9309 specifies.
+	PrecedenceRFC9309
+	// PrecedenceGoogle is PrecedenceRFC9309 with the extension of Google's
+	// robots.txt parser: Allow rule of "/dir/index.htm" or "/dir/index.html"
+	// also allows "/dir/" exactly, as if "Allow: /dir/$" was written.
+	// Rules and Explain report the written rule.
+	PrecedenceGoogle
+	// PrecedenceFirstMatch picks the first matching rule in robots.txt
+	// order, as the original 1994 standard did.
+	PrecedenceFirstMatch
+	// PrecedenceMostRestrictive picks a Disallow rule if any matches, the
+	// longest one, earlier on a tie. Allow rules decide only when no
+	// Disallow rule matches.
+	PrecedenceMostRestrictive
+)
+
+var precedenceNames = [...]string{
+	PrecedenceDefault:         "default",
+	PrecedenceLongest:         "longest",
+	PrecedenceRFC9309:         "rfc9309",
+	PrecedenceGoogle:          "google",
+	PrecedenceFirstMatch:      "first-match",
+	PrecedenceMostRestrictive: "most-restrictive",
+}
+
+func (p Precedence) String() string {
+	if p >= 0 && int(p) < len(precedenceNames) {
+		return precedenceNames[p]
+	}
+	return "precedence(" + strconv.Itoa(int(p)) + ")"
+}
+
+// parsePrecedence is the reverse of Precedence.String.
+func parsePrecedence(s string) (Precedence, bool) {
+	for p, name := range precedenceNames {
+		if name == s {
+			return Precedence(p), true
+		}
+	}
+	return 0, false
+}
+
+// resolve returns the effective precedence, never PrecedenceDefault.
+func (p Precedence) resolve(rfc9309 bool) Precedence {
+	if p != PrecedenceDefault {
+		return p
+	}
+	if rfc9309 {
+		return PrecedenceRFC9309
+	}
+	return PrecedenceLongest
+}
+
+// outranks reports whether rule r, index i in the group, matching l octets
+// takes precedence over best, index bestIndex, matching bestLen octets.
+// It is a strict total order on matches of a path.
+func (p Precedence) outranks(r *rule, i, l int, best *rule, bestIndex, bestLen int) bool {
+	if best == nil {
+		return true
+	}
+	switch p {
+	case PrecedenceFirstMatch:
+		return i < bestIndex
+	case PrecedenceMostRestrictive:
+		if r.allow != best.allow {
+			return !r.allow
+		}
+	}
+	if l != bestLen {
+		return l > bestLen
+	}
+	// From RFC 9309:
+	// If an allow rule and a disallow rule are equivalent, then the allow
+	// rule SHOULD be used.
+	if (p == PrecedenceRFC9309 || p == PrecedenceGoogle) && r.allow != best.allow {
+		return r.allow
+	}
+	return i < bestIndex
+}
+
+// lengthFirst reports whether a longer match always outranks a shorter one.
+func (p Precedence) lengthFirst() bool {
+	return p != PrecedenceFirstMatch && p != PrecedenceMostRestrictive
+}
+
+// setPrecedence sets p for groups and prepares their rules for it.
+func setPrecedence(groups map[string]*Group, p Precedence) {
+	for _, g := range groups {
+		g.precedence = p
+		for _, r := range g.rules {
+			r.implied = nil
+			if p == PrecedenceGoogle {
+				r.implied = googleImplied(r)
+			}
+		}
+	}
+}
+
+// googleImplied returns "/dir/$" pattern that Google's parser adds for
+// Allow rule of "/dir/index.htm" or "/dir/index.html", nil otherwise.
+func googleImplied(r *rule) *glob {
+	if !r.allow {
+		return nil
+	}
+	slash := strings.LastIndexByte(r.path, '/')
+	if slash < 0 || !strings.HasPrefix(r.path[slash:], "/index.htm") {
+		return nil
+	}
+	return newGlob(r.path[:slash+1] + "$")
+}
@@ -0,0 +1,142 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var precedences = []Precedence{PrecedenceDefault, PrecedenceLongest, PrecedenceRFC9309, PrecedenceGoogle, PrecedenceFirstMatch, PrecedenceMostRestrictive}
+
+func TestPrecedence(t *testing.T) {
+	t.Parallel()
+	const robots = `User-agent: *
+Disallow: /page
+Allow: /page
+Allow: /a
+Disallow: /a/b
+Disallow: /dir/
+Allow: /dir/index.html
+Allow: /*.htm
+Disallow: /x/*.htm
+`
+	cases := []struct {
+		path   string
+		expect map[Precedence]bool
+	}{
+		{"/page", map[Precedence]bool{PrecedenceLongest: false, PrecedenceRFC9309: true, PrecedenceGoogle: true, PrecedenceFirstMatch: false, PrecedenceMostRestrictive: false}},
+		{"/a/b", map[Precedence]bool{PrecedenceLongest: false, PrecedenceRFC9309: false, PrecedenceGoogle: false, PrecedenceFirstMatch: true, PrecedenceMostRestrictive: false}},
+		{"/a/c", map[Precedence]bool{PrecedenceLongest: true, PrecedenceRFC9309: true, PrecedenceGoogle: true, PrecedenceFirstMatch: true, PrecedenceMostRestrictive: true}},
+		{"/dir/", map[Precedence]bool{PrecedenceLongest: false, PrecedenceRFC9309: false, PrecedenceGoogle: true, PrecedenceFirstMatch: false, PrecedenceMostRestrictive: false}},
+		{"/dir/x", map[Precedence]bool{PrecedenceLongest: false, PrecedenceRFC9309: false, PrecedenceGoogle: false, PrecedenceFirstMatch: false, PrecedenceMostRestrictive: false}},
+		{"/dir/index.html", map[Precedence]bool{PrecedenceLongest: true, PrecedenceRFC9309: true, PrecedenceGoogle: true, PrecedenceFirstMatch: false, PrecedenceMostRestrictive: false}},
+		{"/x/y.htm", map[Precedence]bool{PrecedenceLongest: false, PrecedenceRFC9309: false, PrecedenceGoogle: false, PrecedenceFirstMatch: true, PrecedenceMostRestrictive: false}},
+	}
+	for _, p := range precedences[1:] {
+		for _, compile := range []bool{false, true} {
+			r, err := Options{Precedence: p, Compile: compile}.FromString(robots)
+			require.NoError(t, err)
+			for _, c := range cases {
+				assert.Equal(t, c.expect[p], r.TestAgent(c.path, "bot"), "precedence %v compile %t path %q", p, compile, c.path)
+			}
+		}
+	}
+
+	// Explain reports the written rule of the implied pattern.
+	r, err := Options{Precedence: PrecedenceGoogle}.FromString(robots)
+	require.NoError(t, err)
+	e := r.Explain("/dir/", "bot")
+	require.NotNil(t, e.Rule)
+	assert.Equal(t, "/dir/index.html", e.Rule.Path)
+}
+
+func TestPrecedenceDefault(t *testing.T) {
+	t.Parallel()
+	const robots = "User-agent: *\nDisallow: /page\nAllow: /page\n"
+	for _, rfc9309 := range []bool{false, true} {
+		def, err := Options{RFC9309: rfc9309}.FromString(robots)
+		require.NoError(t, err)
+		explicit, err := Options{RFC9309: rfc9309, Precedence: PrecedenceDefault.resolve(rfc9309)}.FromString(robots)
+		require.NoError(t, err)
+		assert.Equal(t, explicit.TestAgent("/page", "bot"), def.TestAgent("/page", "bot"))
+		assert.Equal(t, rfc9309, def.TestAgent("/page", "bot"))
+	}
+
+	r, err := Options{Precedence: PrecedenceFirstMatch}.NewBuilder().Group("*").Disallow("/a").Allow("/a/b").Build()
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/a/b", "bot")
+}
+
+func TestPrecedenceGoogleImplied(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		path    string
+		allow   bool
+		implied string
+	}{
+		{"/dir/index.html", true, "/dir/$"},
+		{"/dir/index.htm", true, "/dir/$"},
+		{"/dir/index.html?q", true, "/dir/$"},
+		{"/*/index.html", true, "/*/$"},
+		{"/index.html", true, "/$"},
+		{"/dir/index.html", false, ""},
+		{"/dir/index.php", true, ""},
+		{"/dir/index.html/", true, ""},
+	} {
+		var expect *glob
+		if c.implied != "" {
+			expect = newGlob(c.implied)
+		}
+		assert.Equal(t, expect, googleImplied(&rule{path: c.path, allow: c.allow}), c.path)
+	}
+}
+
+func TestPrecedenceEncoding(t *testing.T) {
+	t.Parallel()
+	const robots = "User-agent: *\nDisallow: /dir/\nAllow: /dir/index.html\nAllow: /a\nDisallow: /a/b\n"
+	paths := []string{"/dir/", "/dir/index.html", "/a/b"}
+	for _, p := range precedences {
+		for _, rfc9309 := range []bool{false, true} {
+			r, err := Options{RFC9309: rfc9309, Precedence: p}.FromString(robots)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(r)
+			require.NoError(t, err)
+			var fromJSON RobotsData
+			require.NoError(t, json.Unmarshal(data, &fromJSON))
+			assert.Equal(t, r.precedence, fromJSON.precedence, string(data))
+
+			data, err = json.Marshal(r.FindGroup("bot"))
+			require.NoError(t, err)
+			var group Group
+			require.NoError(t, json.Unmarshal(data, &group))
+
+			data, err = r.MarshalBinary()
+			require.NoError(t, err)
+			var fromBinary RobotsData
+			require.NoError(t, fromBinary.UnmarshalBinary(data))
+			assert.Equal(t, r.precedence, fromBinary.precedence)
+
+			for _, path := range paths {
+				expect := r.TestAgent(path, "bot")
+				assert.Equal(t, expect, fromJSON.TestAgent(path, "bot"), "%v %q", p, path)
+				assert.Equal(t, expect, group.Test(path), "%v %q", p, path)
+				assert.Equal(t, expect, fromBinary.TestAgent(path, "bot"), "%v %q", p, path)
+			}
+		}
+	}
+
+	var r RobotsData
+	assert.EqualError(t, json.Unmarshal([]byte(`{"version":1,"precedence":"shortest"}`), &r), `robotstxt: unknown precedence "shortest"`)
+	var g Group
+	assert.EqualError(t, json.Unmarshal([]byte(`{"version":1,"agent":"a","precedence":"default"}`), &g), `robotstxt: unknown precedence "default"`)
+	assert.EqualError(t, r.UnmarshalBinary([]byte("RTXT\x01\x80\x03")), "robotstxt: unknown binary precedence 6")
+}
+
+func TestPrecedenceString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "most-restrictive", PrecedenceMostRestrictive.String())
+	assert.Equal(t, "precedence(9)", Precedence(9).String())
+}
@@ -0,0 +1,222 @@
+package robotstxt
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Conformance tests for RFC 9309 mode, grouped by section of
+// https://www.rfc-editor.org/rfc/rfc9309.html
+
+var rfc9309 = Options{RFC9309: true}
+
+// 2.1. Protocol Definition
+func TestRFC9309ProtocolDefinition(t *testing.T) {
+	t.Parallel()
+	r, err := rfc9309.FromString("\xef\xbb\xbf# comment\nUser-Agent: *\nDisallow: /a # trailing comment\n")
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/a", "foobot")
+	expectAccess(t, r, true, "/b", "foobot")
+}
+
+// 2.2.1. The User-Agent Line
+func TestRFC9309UserAgent(t *testing.T) {
+	t.Parallel()
+	const input = `User-Agent: ExampleBot
+Disallow: /example
+User-Agent: example
+Disallow: /prefix
+User-Agent: *
+Disallow: /star
+`
+	r, err := rfc9309.FromString(input)
+	require.NoError(t, err)
+
+	// Case-insensitive match on the product token.
+	assert.Equal(t, "examplebot", r.FindGroup("examplebot").Agent)
+	assert.Equal(t, "examplebot", r.FindGroup("EXAMPLEBOT").Agent)
+	assert.Equal(t, "examplebot", r.FindGroup("ExampleBot/1.0").Agent)
+	// Product token must match exactly, not by prefix.
+	assert.Equal(t, "example", r.FindGroup("example").Agent)
+	assert.Equal(t, "*", r.FindGroup("examplebotnews").Agent)
+	// No match falls back to "*".
+	assert.Equal(t, "*", r.FindGroup("otherbot").Agent)
+}
+
+// 5.1. Simple Example, exercises grouping rules of 2.2.1.
+func TestRFC9309SimpleExample(t *testing.T) {
+	t.Parallel()
+	const input = `User-Agent: *
+Disallow: *.gif$
+Disallow: /example/
+Allow: /publications/
+
+User-Agent: foobot
+Disallow:/
+Allow:/example/page.html
+Allow:/example/allowed.gif
+
+User-Agent: barbot
+User-Agent: bazbot
+Disallow: /example/page.html
+
+User-Agent: quxbot
+
+EOF`
+	r, err := rfc9309.FromString(input)
+	require.NoError(t, err)
+
+	expectAccess(t, r, false, "/image.gif", "otherbot")
+	expectAccess(t, r, false, "/example/x", "otherbot")
+	expectAccess(t, r, true, "/publications/x", "otherbot")
+
+	expectAccess(t, r, true, "/example/page.html", "foobot")
+	expectAccess(t, r, true, "/example/allowed.gif", "foobot")
+	expectAccess(t, r, false, "/other", "foobot")
+
+	expectAccess(t, r, false, "/example/page.html", "barbot")
+	expectAccess(t, r, false, "/example/page.html", "bazbot")
+	expectAccess(t, r, true, "/image.gif", "bazbot")
+
+	expectAccess(t, r, true, "/example/page.html", "quxbot")
+	expectAccess(t, r, true, "/image.gif", "quxbot")
+}
+
+// 2.2.2. The "Allow" and "Disallow" Lines
+func TestRFC9309AllowDisallow(t *testing.T) {
+	t.Parallel()
+	t.Run("longest-match", func(t *testing.T) {
+		r, err := rfc9309.FromString("User-Agent: *\nDisallow: /a\nAllow: /a/b\nDisallow: /a/b/c\n")
+		require.NoError(t, err)
+		expectAccess(t, r, false, "/a", "foobot")
+		expectAccess(t, r, true, "/a/b", "foobot")
+		expectAccess(t, r, false, "/a/b/c", "foobot")
+	})
+	t.Run("equivalent-allow-wins", func(t *testing.T) {
+		r, err := rfc9309.FromString("User-Agent: *\nDisallow: /page\nAllow: /page\nDisallow: /*.htm\nAllow: /pag*m\n")
+		require.NoError(t, err)
+		expectAccess(t, r, true, "/page", "foobot")
+		// "/*.htm" and "/pag*m" both have 6 octets.
+		expectAccess(t, r, true, "/page.htm", "foobot")
+	})
+	t.Run("wildcard-octets", func(t *testing.T) {
+		r, err := rfc9309.FromString("User-Agent: *\nDisallow: /*.php\nAllow: /index\n")
+		require.NoError(t, err)
+		// "/index" is longer than "/*.php" as written.
+		expectAccess(t, r, true, "/index.php", "foobot")
+	})
+	t.Run("empty-disallow", func(t *testing.T) {
+		r, err := rfc9309.FromString("User-Agent: *\nDisallow:\n")
+		require.NoError(t, err)
+		expectAccess(t, r, true, "/", "foobot")
+	})
+
+	// Percent-encoding examples
+	cases := []struct {
+		rule string
+		path string
+	}{
+		{"/foo/bar?baz=quz", "/foo/bar?baz=quz"},
+		{"/foo/bar/ツ", "/foo/bar/%E3%83%84"},
+		{"/foo/bar/%E3%83%84", "/foo/bar/%E3%83%84"},
+		{"/foo/bar/%e3%83%84", "/foo/bar/ツ"},
+		{"/foo/bar/%62%61%7A", "/foo/bar/baz"},
+		{"/foo/bar/baz", "/foo/bar/%62%61%7A"},
+		{"/~joe", "/%7Ejoe"},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r, err := rfc9309.FromString("User-Agent: *\nDisallow: " + c.rule + "\n")
+			require.NoError(t, err)
+			expectAccess(t, r, false, c.path, "foobot")
+		})
+	}
+	t.Run("reserved-keeps-meaning", func(t *testing.T) {
+		r, err := rfc9309.FromString("User-Agent: *\nDisallow: /a%2Fb\n")
+		require.NoError(t, err)
+		expectAccess(t, r, true, "/a/b", "foobot")
+		expectAccess(t, r, false, "/a%2fb", "foobot")
+	})
+}
+
+// 2.2.3. Special Characters
+func TestRFC9309SpecialCharacters(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		rule  string
+		path  string
+		allow bool
+	}{
+		{"/ # comment in line", "/", true},
+		{"/this/path/exactly$", "/this/path/exactly", true},
+		{"/this/path/exactly$", "/this/path/exactly/not", false},
+		{"/this/*/exactly", "/this/is/exactly", true},
+		{"/this/*/exactly", "/this/exactly", false},
+		{"/path/file-with-a-%2A.html", "/path/file-with-a-*.html", true},
+		{"/path/file-with-a-%2A.html", "/path/file-with-a-b.html", false},
+		{"/path/foo-%24", "/path/foo-$", true},
+	}
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r, err := rfc9309.FromString("User-Agent: *\nDisallow: /\nAllow: " + c.rule + "\n")
+			require.NoError(t, err)
+			expectAccess(t, r, c.allow, c.path, "foobot")
+		})
+	}
+}
+
+// 2.2.4. Other Records
+func TestRFC9309OtherRecords(t *testing.T) {
+	t.Parallel()
+	r, err := rfc9309.FromString("User-Agent: *\nDisallow: /a\nSitemap: https://example.com/sitemap.xml\nFoo: bar\nDisallow: /b\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, r.Sitemaps)
+	// Sitemap and unknown records do not end the group.
+	expectAccess(t, r, false, "/b", "foobot")
+}
+
+// 2.3.1.3. "Unavailable" Status
+// 2.3.1.4. "Unreachable" Status
+func TestRFC9309Status(t *testing.T) {
+	t.Parallel()
+	for _, code := range []int{400, 401, 403, 404, 410, 499} {
+		r, err := rfc9309.FromStatusAndString(code, "User-Agent: *\nDisallow: /\n")
+		require.NoError(t, err)
+		expectAll(t, r, true)
+	}
+	for _, code := range []int{500, 502, 503, 599} {
+		r, err := rfc9309.FromStatusAndString(code, "")
+		require.NoError(t, err)
+		expectAll(t, r, false)
+	}
+}
+
+// 2.5. Limits
+func TestRFC9309Limits(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	b.WriteString("User-Agent: *\nDisallow: /before\n")
+	for b.Len() < rfc9309MaxSize-16 {
+		b.WriteString("# padding\n")
+	}
+	// This line crosses the limit and must be dropped as a whole.
+	b.WriteString("Disallow: /crossing-the-limit\n")
+	b.WriteString("Disallow: /after\n")
+	r, err := rfc9309.FromString(b.String())
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/before", "foobot")
+	expectAccess(t, r, true, "/crossing-the-limit", "foobot")
+	expectAccess(t, r, true, "/cross", "foobot")
+	expectAccess(t, r, true, "/after", "foobot")
+	assert.True(t, r.Truncated)
+
+	// Negative MaxSize parses everything.
+	r, err = Options{MaxSize: -1}.FromString(b.String())
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/after", "foobot")
+	assert.False(t, r.Truncated)
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
-	"net/http"
 	"strings"
 
 	"github.com/temoto/robotstxt"
@@ -14,7 +14,7 @@ var checkPaths = []string{
 }
 
 func main() {
-	robotsUrl := flag.String("robots-url", "", "")
+	robotsUrl := flag.String("robots-url", "", "URL of robots.txt or any page of the site, robots.txt is fetched from its root")
 	bot := flag.String("bot", "GoogleBot", "")
 	flag.Parse()
 	if *robotsUrl == "" {
@@ -24,12 +24,8 @@ func main() {
 		*robotsUrl = "http://" + *robotsUrl
 	}
 
-	response, err := http.Get(*robotsUrl)
-	if err != nil {
-		log.Fatalln("HTTP error:", err)
-	}
-
-	robots, err := robotstxt.FromResponse(response)
+	var fetcher robotstxt.Fetcher
+	robots, err := fetcher.Fetch(context.Background(), *robotsUrl)
 	if err != nil {
 		log.Fatalln("Robots.txt error:", err)
 	}
@@ -1,18 +1,23 @@
 // Package robotstxt implements the robots.txt Exclusion Protocol
 // as specified in http://www.robotstxt.org/wc/robots.html
 // with various extensions.
+//
+// The protocol is standardized as RFC 9309
+// https://www.rfc-editor.org/rfc/rfc9309.html
+// which is available as an explicit mode, see Options.RFC9309.
 package robotstxt
 
 // Comments explaining the logic are taken from either the Google's spec:
 // https://developers.google.com/webmasters/control-crawl-index/docs/robots_txt
+//
+// or RFC 9309:
+// https://www.rfc-editor.org/rfc/rfc9309.html
 
 import (
 	"bytes"
-	"errors"
-	"io/ioutil"
+	"go/token"
 	"net/http"
-	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,11 +25,29 @@ import (
 type RobotsData struct {
 	Host     string
 	Sitemaps []string
+	// Warnings lists problems that did not prevent parsing: suspicious
+	// encoding and, with Options.Lenient, skipped invalid lines.
+	// Every entry is a *Diagnostic.
+	Warnings []error
+	// Truncated tells that content was longer than Options.MaxSize, only
+	// lines before the limit were parsed.
+	Truncated bool
 
 	// private
-	allowAll    bool
-	disallowAll bool
-	groups      map[string]*Group
+	allowAll      bool
+	disallowAll   bool
+	fromStatus    bool // allowAll or disallowAll come from HTTP status
+	rfc9309       bool
+	groups        map[string]*Group
+	match         MatchMode         // resolved Options.MatchMode
+	precedence    Precedence        // resolved Options.Precedence
+	agents        *agentNode        // index of groups by agent for MatchPrefix, except "*"
+	tokens        map[string]*Group // groups by product token for MatchGoogle
+	cache         *agentCache       // see Options.AgentCacheSize
+	origin        string            // see Options.Origin
+	etag          string            // ETag header of the response
+	lastModified  string            // Last-Modified header of the response
+	fullUserAgent bool              // see Options.FullUserAgent
 }
 
 type Group struct {
@@ -33,14 +56,37 @@ type Group struct {
 	CrawlDelay time.Duration
 
 	disallowAll bool
+	precedence  Precedence // resolved Options.Precedence
+	index       *ruleIndex // set by RobotsData.Compile
 }
 
 type rule struct {
 	path    string
 	allow   bool
-	pattern *regexp.Regexp
+	pattern *glob
+	text    string         // path as written in robots.txt
+	pos     token.Position // position of the directive
+	// implied is "/dir/$" pattern of Allow "/dir/index.html" with
+	// PrecedenceGoogle.
+	implied *glob
+}
+
+// Rule is a read-only description of a single Allow or Disallow line.
+type Rule struct {
+	// Text is the path as written in robots.txt.
+	Text string
+	// Path is the normalized value used for matching: with leading "/",
+	// without trailing "*" and with percent-encoding normalized.
+	Path string
+	// Wildcard tells that Path is a pattern with "*" or "$".
+	Wildcard bool
+	Allow    bool
+	// Pos is the position of the directive in robots.txt.
+	Pos token.Position
 }
 
+// ParseError is returned when robots.txt content has invalid lines.
+// Every entry of Errs is a *Diagnostic with position of the problem.
 type ParseError struct {
 	Errs []error
 }
@@ -65,33 +111,49 @@ func (e ParseError) Unwrap() []error {
 	return e.Errs
 }
 
-var allowAll = &RobotsData{allowAll: true}
-var disallowAll = &RobotsData{disallowAll: true}
+var allowAll = &RobotsData{allowAll: true, fromStatus: true}
+var disallowAll = &RobotsData{disallowAll: true, fromStatus: true}
+var emptyRobots = &RobotsData{allowAll: true}
 var emptyGroup = &Group{}
 var emptyDisallowGroup = &Group{disallowAll: true}
 
-func FromStatusAndBytes(statusCode int, body []byte) (*RobotsData, error) {
-	switch {
-	case statusCode >= 200 && statusCode < 300:
-		return FromBytes(body)
-
-	// From https://developers.google.com/webmasters/control-crawl-index/docs/robots_txt
-	//
-	// Google treats all 4xx errors in the same way and assumes that no valid
-	// robots.txt file exists. It is assumed that there are no restrictions.
-	// This is a "full allow" for crawling. Note: this includes 401
-	// "Unauthorized" and 403 "Forbidden" HTTP result codes.
-	case statusCode >= 400 && statusCode < 500:
-		return allowAll, nil
+// Groups returns a copy of every group, sorted by agent.
+// Changes to returned groups do not affect r.
+func (r *RobotsData) Groups() []*Group {
+	agents := r.Agents()
+	groups := make([]*Group, len(agents))
+	for i, a := range agents {
+		g := *r.groups[a]
+		groups[i] = &g
+	}
+	return groups
+}
 
-	// From Google's spec:
-	// Server errors (5xx) are seen as temporary errors that result in a "full
-	// disallow" of crawling.
-	case statusCode >= 500 && statusCode < 600:
-		return disallowAll, nil
+// Agents returns lower case user-agents of all groups, sorted.
+func (r *RobotsData) Agents() []string {
+	agents := make([]string, 0, len(r.groups))
+	for a := range r.groups {
+		agents = append(agents, a)
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+// Rules returns Allow and Disallow rules of the group in robots.txt order.
+func (g *Group) Rules() []Rule {
+	rules := make([]Rule, len(g.rules))
+	for i, r := range g.rules {
+		rules[i] = r.export()
 	}
+	return rules
+}
+
+func (r *rule) export() Rule {
+	return Rule{Text: r.text, Path: r.path, Wildcard: r.pattern != nil, Allow: r.allow, Pos: r.pos}
+}
 
-	return nil, errors.New("Unexpected status: " + strconv.Itoa(statusCode))
+func FromStatusAndBytes(statusCode int, body []byte) (*RobotsData, error) {
+	return Options{}.FromStatusAndBytes(statusCode, body)
 }
 
 func FromStatusAndString(statusCode int, body string) (*RobotsData, error) {
@@ -99,44 +161,11 @@ func FromStatusAndString(statusCode int, body string) (*RobotsData, error) {
 }
 
 func FromResponse(res *http.Response) (*RobotsData, error) {
-	if res == nil {
-		// Edge case, if res is nil, return nil data
-		return nil, nil
-	}
-	buf, e := ioutil.ReadAll(res.Body)
-	if e != nil {
-		return nil, e
-	}
-	return FromStatusAndBytes(res.StatusCode, buf)
+	return Options{}.FromResponse(res)
 }
 
 func FromBytes(body []byte) (r *RobotsData, err error) {
-	var errs []error
-
-	// special case (probably not worth optimization?)
-	trimmed := bytes.TrimSpace(body)
-	if len(trimmed) == 0 {
-		return allowAll, nil
-	}
-
-	sc := newByteScanner("bytes", true)
-	//sc.Quiet = !print_errors
-	sc.feed(body, true)
-	tokens := sc.scanAll()
-
-	// special case worth optimization
-	if len(tokens) == 0 {
-		return allowAll, nil
-	}
-
-	r = &RobotsData{}
-	parser := newParser(tokens)
-	r.groups, r.Host, r.Sitemaps, errs = parser.parseAll()
-	if len(errs) > 0 {
-		return nil, newParseError(errs)
-	}
-
-	return r, nil
+	return Options{}.FromBytes(body)
 }
 
 func FromString(body string) (r *RobotsData, err error) {
@@ -165,37 +194,83 @@ func (r *RobotsData) TestAgent(path, agent string) bool {
 // with the most specific user-agent that still matches. All other groups of
 // records are ignored by the crawler. The user-agent is non-case-sensitive.
 // The order of the groups within the robots.txt file is irrelevant.
-func (r *RobotsData) FindGroup(agent string) (ret *Group) {
-	var prefixLen int
+func (r *RobotsData) FindGroup(agent string) *Group {
+	if r.cache == nil {
+		return r.findGroup(agent)
+	}
+	if g, ok := r.cache.get(agent); ok {
+		return g
+	}
+	g := r.findGroup(agent)
+	r.cache.put(agent, g)
+	return g
+}
 
-	agent = strings.ToLower(agent)
-	if ret = r.groups["*"]; ret != nil {
-		// Weakest match possible
-		prefixLen = 1
-	}
-	for a, g := range r.groups {
-		if a != "*" && strings.HasPrefix(agent, a) {
-			if l := len(a); l > prefixLen {
-				prefixLen = l
-				ret = g
-			}
+func (r *RobotsData) findGroup(agent string) *Group {
+	if g := r.matchAgent(agent); g != nil {
+		return g
+	}
+	return r.defaultGroup()
+}
+
+// FindGroupChain returns the group of the first agent in agents that has
+// a group of its own, falling back to "*" group. This is how crawlers with
+// a hierarchy choose, e.g. Googlebot-Image obeys "googlebot-image" group,
+// otherwise "googlebot", otherwise "*". See AgentHierarchy for known
+// chains.
+func (r *RobotsData) FindGroupChain(agents ...string) *Group {
+	for _, a := range agents {
+		if g := r.matchAgent(a); g != nil {
+			return g
 		}
 	}
+	return r.defaultGroup()
+}
 
-	if ret == nil {
-		if r.disallowAll {
-			return emptyDisallowGroup
-		} else {
-			return emptyGroup
+// matchAgent returns the group for agent other than "*", nil if none.
+func (r *RobotsData) matchAgent(agent string) *Group {
+	if r.fullUserAgent {
+		agent = ProductToken(agent)
+	}
+	agent = strings.ToLower(agent)
+	switch r.match {
+	case MatchExactToken:
+		// From RFC 9309:
+		// Crawlers MUST use case-insensitive matching to find the group that
+		// matches the product token and then obey the rules of the group.
+		if token := leadingToken(agent); token != "" {
+			return r.groups[token]
+		}
+	case MatchGoogle:
+		return r.tokens[leadingToken(agent)]
+	case MatchPrefix:
+		if r.agents == nil {
+			break
+		}
+		// "*" group is the weakest match possible, equal to length 1.
+		if g, l := r.agents.longestPrefix(agent); l > 1 || l == 1 && r.groups["*"] == nil {
+			return g
 		}
 	}
-	return
+	return nil
+}
+
+// defaultGroup returns "*" group or empty group when it does not exist.
+func (r *RobotsData) defaultGroup() *Group {
+	if g := r.groups["*"]; g != nil {
+		return g
+	}
+	if r.disallowAll {
+		return emptyDisallowGroup
+	}
+	return emptyGroup
 }
 
 func (g *Group) Test(path string) bool {
 	if g.disallowAll {
 		return false
 	}
+	path = normalizeURLPath(path)
 	if r := g.findRule(path); r != nil {
 		return r.allow
 	}
@@ -216,29 +291,66 @@ func (g *Group) Test(path string) bool {
 // the less specific (shorter) rule. The order of precedence for rules with
 // wildcards is undefined.
 func (g *Group) findRule(path string) (ret *rule) {
-	var prefixLen int
-
-	for _, r := range g.rules {
-		if r.pattern != nil {
-			if r.pattern.MatchString(path) {
-				// Consider this a match equal to the length of the pattern.
-				// From Google's spec:
-				// The order of precedence for rules with wildcards is undefined.
-				if l := len(r.pattern.String()); l > prefixLen {
-					prefixLen = l
-					ret = r
-				}
-			}
-		} else if r.path == "/" && prefixLen == 0 {
-			// Weakest match possible
-			prefixLen = 1
+	if g.index != nil {
+		return g.index.find(path)
+	}
+	ret, _ = g.matchRules(path, false)
+	return
+}
+
+// matchRules finds the rule deciding path as described for findRule.
+// With collect set, it also returns every rule that matches path.
+func (g *Group) matchRules(path string, collect bool) (ret *rule, matched []*rule) {
+	var prefixLen, retIndex int
+
+	for i, r := range g.rules {
+		l, ok := r.match(path)
+		if !ok {
+			continue
+		}
+		if collect {
+			matched = append(matched, r)
+		}
+		if g.precedence.outranks(r, i, l, ret, retIndex, prefixLen) {
+			prefixLen = l
+			retIndex = i
 			ret = r
-		} else if strings.HasPrefix(path, r.path) {
-			if l := len(r.path); l > prefixLen {
-				prefixLen = l
-				ret = r
-			}
 		}
 	}
 	return
 }
+
+// match reports whether r matches path and the length of the match.
+func (r *rule) match(path string) (l int, ok bool) {
+	switch {
+	case r.pattern != nil:
+		if r.pattern.match(path) {
+			// Consider this a match equal to the length of the pattern
+			// as written, including wildcards.
+			// From RFC 9309:
+			// The most specific match found MUST be used. The most specific
+			// match is the match that has the most octets.
+			return len(r.path), true
+		}
+	case r.path == "/":
+		// Weakest match possible
+		return 1, true
+	case strings.HasPrefix(path, r.path):
+		return len(r.path), true
+	}
+	if r.implied != nil && r.implied.match(path) {
+		return r.implied.n, true
+	}
+	return 0, false
+}
+
+// leadingToken returns the leading run of characters allowed in a
+// user-agent product token, e.g. "googlebot" for "googlebot/2.1".
+func leadingToken(agent string) string {
+	for i := 0; i < len(agent); i++ {
+		if c := agent[i]; !(c == '-' || c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return agent[:i]
+		}
+	}
+	return agent
+}
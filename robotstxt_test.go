@@ -1,12 +1,15 @@
 package robotstxt
 
 import (
+	"go/token"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -177,16 +180,18 @@ disallow: /
 user-agent: *
 allow: /`
 
-	r, err := FromString(robotsTextSimple)
-	require.NoError(t, err)
+	for _, mode := range matchModes {
+		r, err := Options{MatchMode: mode}.FromString(robotsTextSimple)
+		require.NoError(t, err)
 
-	group := r.FindGroup("eve")
-	require.NotNil(t, group)
-	assert.Equal(t, "*", group.Agent)
+		group := r.FindGroup("eve")
+		require.NotNil(t, group)
+		assert.Equal(t, "*", group.Agent, mode)
 
-	group = r.FindGroup("wall-e")
-	require.NotNil(t, group)
-	assert.Equal(t, "wall-e", group.Agent)
+		group = r.FindGroup("wall-e")
+		require.NotNil(t, group)
+		assert.Equal(t, "wall-e", group.Agent, mode)
+	}
 }
 
 // http://perche.vanityfair.it/robots.txt on Sat, 13 Sep 2014 23:00:29 GMT
@@ -240,6 +245,70 @@ func TestDifferentApiDisallowAll(t *testing.T) {
 	assert.Equal(t, false, b, "Expected false (disallow) from FindGroup(*).Test(/)")
 }
 
+func TestGroupsAndRules(t *testing.T) {
+	t.Parallel()
+	const input = `User-agent: bbot
+User-agent: ABot
+Disallow: fish*
+Allow: /fish/ok
+
+User-agent: *
+Crawl-delay: 2
+Disallow: /*.php$
+`
+	r, err := FromString(input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*", "abot", "bbot"}, r.Agents())
+
+	groups := r.Groups()
+	require.Len(t, groups, 3)
+	assert.Equal(t, "*", groups[0].Agent)
+	assert.Equal(t, 2*time.Second, groups[0].CrawlDelay)
+	assert.Equal(t, []Rule{
+		{Text: "/*.php$", Path: "/*.php$", Wildcard: true, Allow: false,
+			Pos: token.Position{Filename: "bytes", Offset: 96, Line: 8, Column: 1}},
+	}, groups[0].Rules())
+
+	assert.Equal(t, "abot", groups[1].Agent)
+	rules := groups[1].Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, Rule{Text: "fish*", Path: "/fish", Allow: false,
+		Pos: token.Position{Filename: "bytes", Offset: 34, Line: 3, Column: 1}}, rules[0])
+	assert.Equal(t, "/fish/ok", rules[1].Path)
+	assert.True(t, rules[1].Allow)
+	assert.Equal(t, rules, groups[2].Rules())
+
+	// Returned values are copies.
+	groups[1].Agent = "changed"
+	groups[1].CrawlDelay = time.Hour
+	rules[0].Allow = true
+	assert.Equal(t, "abot", r.FindGroup("abot").Agent)
+	assert.Zero(t, r.FindGroup("abot").CrawlDelay)
+	expectAccess(t, r, false, "/fish", "abot")
+}
+
+func TestGroupsConcurrent(t *testing.T) {
+	t.Parallel()
+	r, err := FromString(robotsGoogle)
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, g := range r.Groups() {
+				g.Agent = "x"
+				_ = g.Rules()
+			}
+			_ = r.Agents()
+			r.TestAgent("/search", "bot")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"*"}, r.Agents())
+	assert.Equal(t, "*", r.FindGroup("bot").Agent)
+}
+
 func BenchmarkParseFromString001(b *testing.B) {
 	input := robotsText001
 	b.ReportAllocs()
@@ -252,6 +321,48 @@ func BenchmarkParseFromString001(b *testing.B) {
 	}
 }
 
+func BenchmarkUnmarshalBinary001(b *testing.B) {
+	benchmarkUnmarshalBinary(b, robotsText001)
+}
+
+func BenchmarkUnmarshalBinary002(b *testing.B) {
+	benchmarkUnmarshalBinary(b, robotsGoogle)
+}
+
+func benchmarkUnmarshalBinary(b *testing.B, input string) {
+	r, err := FromString(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var decoded RobotsData
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalBinary001(b *testing.B) {
+	r, err := FromString(robotsText001)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkParseFromString002(b *testing.B) {
 	input := robotsGoogle
 	b.ReportAllocs()
@@ -299,5 +410,6 @@ func newHttpResponse(code int, body string) *http.Response {
 		ProtoMinor:    1,
 		Body:          ioutil.NopCloser(strings.NewReader(body)),
 		ContentLength: int64(len(body)),
+		Header:        http.Header{},
 	}
 }
@@ -2,72 +2,113 @@ package robotstxt
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"go/token"
-	"os"
 	"slices"
+	"strconv"
 	"sync"
 	"unicode/utf8"
 )
 
 type byteScanner struct {
-	pos           token.Position
+	pos           token.Position // position of the next byte in buf
+	chPos         token.Position // position of look-ahead char ch
 	buf           []byte
-	ErrorCount    int
+	Diagnostics   []*Diagnostic
 	ch            rune
-	Quiet         bool
 	keyTokenFound bool
 	lastChunk     bool
+	lineStart     int  // offset of the current line
+	lineTooLong   bool // current line is over maxLineLength
+	cut           rune // first ignored char of a line over maxLineLength
+	base          int  // offset of buf in content, see resume
+	// eol ends a comment cut by the end of previous input, see scan.
+	eol scanToken
+}
+
+// scanToken is a token text with the position where it starts.
+type scanToken struct {
+	text string
+	pos  token.Position
+	// truncated is set when the rest of the token was past maxLineLength.
+	truncated bool
 }
 
 const tokEOL = "\n"
 
+// maxLineLength is the number of bytes of a line taken into account,
+// the rest of the line is ignored. Same limit as in Google's parser
+// https://github.com/google/robotstxt
+const maxLineLength = 2083 * 8
+
+var byteOrderMark = []byte{0xef, 0xbb, 0xbf}
+
 var WhitespaceChars = []rune{' ', '\t', '\v'}
 var tokBuffers = sync.Pool{New: func() any { return bytes.NewBuffer(make([]byte, 32)) }}
 
-func newByteScanner(srcname string, quiet bool) *byteScanner {
+func newByteScanner(srcname string) *byteScanner {
 	return &byteScanner{
-		Quiet: quiet,
-		ch:    -1,
-		pos:   token.Position{Filename: srcname},
+		ch:  -1,
+		pos: token.Position{Filename: srcname},
 	}
 }
 
 func (s *byteScanner) feed(input []byte, end bool) {
+	s.pos.Line = 1
+	s.resume(input, 0, end)
+}
+
+// resume continues scanning with input at offset base of content. Previous
+// input must end with a line break, or be cut in the ignored part of a line
+// longer than maxLineLength with input starting at the line break.
+func (s *byteScanner) resume(input []byte, base int, end bool) {
 	s.buf = input
+	s.base = base
 	s.pos.Offset = 0
-	s.pos.Line = 1
 	s.pos.Column = 1
 	s.lastChunk = end
-
-	// Read first char into look-ahead buffer `s.ch`.
-	if !s.nextChar() {
-		return
-	}
+	s.lineStart = 0
+	s.lineTooLong = false
 
 	// Skip UTF-8 byte order mark
-	if s.ch == 65279 {
-		s.nextChar()
-		s.pos.Column = 1
+	if base == 0 && bytes.HasPrefix(input, byteOrderMark) {
+		s.pos.Offset = len(byteOrderMark)
+		s.lineStart = s.pos.Offset
 	}
-}
 
-func (s *byteScanner) GetPosition() token.Position {
-	return s.pos
+	// Read first char into look-ahead buffer `s.ch`.
+	s.nextChar()
 }
 
-func (s *byteScanner) scan() string {
+func (s *byteScanner) scan() scanToken {
 	// Note Offset > len, not >=, so we can scan last character.
 	if s.lastChunk && s.pos.Offset > len(s.buf) {
-		return ""
+		return scanToken{}
+	}
+
+	if s.eol.text != "" {
+		// As for whole content, a comment ends with EOL token unless only
+		// line breaks follow it.
+		for s.ch != -1 && s.isEol() {
+			s.nextChar()
+		}
+		if s.ch == -1 {
+			return scanToken{}
+		}
+		tok := s.eol
+		s.eol = scanToken{}
+		return tok
 	}
 
 	s.skipSpace()
 
 	if s.ch == -1 {
-		return ""
+		return scanToken{}
 	}
 
+	start := s.chPos
+	start.Offset += s.base
+
 	// EOL
 	if s.isEol() {
 		s.keyTokenFound = false
@@ -76,7 +117,7 @@ func (s *byteScanner) scan() string {
 			s.nextChar()
 		}
 		// emit newline as separate token
-		return tokEOL
+		return scanToken{text: tokEOL, pos: start}
 	}
 
 	// skip comments
@@ -84,10 +125,13 @@ func (s *byteScanner) scan() string {
 		s.keyTokenFound = false
 		s.skipUntilEol()
 		if s.ch == -1 {
-			return ""
+			if !s.lastChunk {
+				s.eol = scanToken{text: tokEOL, pos: start}
+			}
+			return scanToken{}
 		}
 		// emit newline as separate token
-		return tokEOL
+		return scanToken{text: tokEOL, pos: start}
 	}
 
 	// else we found something
@@ -95,6 +139,7 @@ func (s *byteScanner) scan() string {
 	defer tokBuffers.Put(tok)
 	tok.Reset()
 	tok.WriteRune(s.ch)
+	s.cut = -1
 	s.nextChar()
 	for s.ch != -1 && !s.isSpace() && !s.isEol() {
 		// Do not consider ":" to be a token separator if a first key token
@@ -103,20 +148,23 @@ func (s *byteScanner) scan() string {
 		if s.ch == ':' && !s.keyTokenFound {
 			s.nextChar()
 			s.keyTokenFound = true
-			break
+			return scanToken{text: tok.String(), pos: start}
 		}
 
 		tok.WriteRune(s.ch)
 		s.nextChar()
 	}
-	return tok.String()
+	// The line was cut right after the token if the ignored part starts
+	// with a space.
+	truncated := s.cut != -1 && !slices.Contains(WhitespaceChars, s.cut)
+	return scanToken{text: tok.String(), pos: start, truncated: truncated}
 }
 
-func (s *byteScanner) scanAll() []string {
-	results := make([]string, 0, 64) // random guess of average tokens length
+func (s *byteScanner) scanAll() []scanToken {
+	results := make([]scanToken, 0, 64) // random guess of average tokens length
 	for {
 		token := s.scan()
-		if token != "" {
+		if token.text != "" {
 			results = append(results, token)
 		} else {
 			break
@@ -125,11 +173,9 @@ func (s *byteScanner) scanAll() []string {
 	return results
 }
 
-func (s *byteScanner) error(pos token.Position, msg string) {
-	s.ErrorCount++
-	if !s.Quiet {
-		fmt.Fprintf(os.Stderr, "robotstxt from %s: %s\n", pos.String(), msg)
-	}
+func (s *byteScanner) error(kind DiagnosticKind, pos token.Position, msg string) {
+	pos.Offset += s.base
+	s.Diagnostics = append(s.Diagnostics, newDiagnostic(kind, SeverityWarning, pos, errors.New(msg)))
 }
 
 func (s *byteScanner) isEol() bool {
@@ -158,24 +204,44 @@ func (s *byteScanner) skipUntilEol() {
 
 // Reads next Unicode char.
 func (s *byteScanner) nextChar() bool {
-	if s.pos.Offset >= len(s.buf) {
-		s.ch = -1
-		return false
-	}
-	s.pos.Column++
-	if s.ch == '\n' {
-		s.pos.Line++
-		s.pos.Column = 1
-	}
-	r, w := rune(s.buf[s.pos.Offset]), 1
-	if r >= 0x80 {
-		r, w = utf8.DecodeRune(s.buf[s.pos.Offset:])
-		if r == utf8.RuneError && w == 1 {
-			s.error(s.pos, "illegal UTF-8 encoding")
+	for {
+		s.chPos = s.pos
+		if s.pos.Offset >= len(s.buf) {
+			s.ch = -1
+			return false
+		}
+		r, w := rune(s.buf[s.pos.Offset]), 1
+		if r >= 0x80 {
+			r, w = utf8.DecodeRune(s.buf[s.pos.Offset:])
+		}
+		s.pos.Offset += w
+		// "\r\n", "\n" and lone "\r" end a line.
+		if r == '\n' || r == '\r' && (s.pos.Offset >= len(s.buf) || s.buf[s.pos.Offset] != '\n') {
+			s.pos.Line++
+			s.pos.Column = 1
+			s.lineStart = s.pos.Offset
+			s.lineTooLong = false
+		} else {
+			s.pos.Column += w
+		}
+
+		if r != '\n' && r != '\r' && s.chPos.Offset-s.lineStart >= maxLineLength {
+			if !s.lineTooLong {
+				s.lineTooLong = true
+				s.cut = r
+				s.error(KindLineTooLong, s.chPos, "line longer than "+strconv.Itoa(maxLineLength)+" bytes, rest of the line is ignored")
+			}
+			continue
+		}
+		switch {
+		case r == utf8.RuneError && w == 1:
+			s.error(KindInvalidUTF8, s.chPos, "illegal UTF-8 encoding")
+		case r == 0:
+			s.error(KindNULByte, s.chPos, "NUL byte")
+		case r == '\uFEFF':
+			s.error(KindByteOrderMark, s.chPos, "byte order mark not at the start of content")
 		}
+		s.ch = r
+		return true
 	}
-	s.pos.Column++
-	s.pos.Offset += w
-	s.ch = r
-	return true
 }
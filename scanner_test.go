@@ -2,9 +2,12 @@ package robotstxt
 
 import (
 	"fmt"
+	"go/token"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanner(t *testing.T) {
@@ -22,15 +25,90 @@ func TestScanner(t *testing.T) {
 		{"# comment \r\n# more comments\n\nDisallow:\r", []string{tokEOL, tokEOL, "Disallow", tokEOL}, 0},
 		{"\xef\xbb\xbfUser-agent: *\n", []string{"User-agent", "*", tokEOL}, 0},
 		{"\xd9\xd9", []string{"\uFFFD\uFFFD"}, 2},
+		{"a\x00b", []string{"a\x00b"}, 1},
+		{"User-agent: *\n\xef\xbb\xbfDisallow: /", []string{"User-agent", "*", tokEOL, "\uFEFFDisallow", "/"}, 1},
 	}
 	for i, c := range cases {
 		tag := fmt.Sprintf("test-%d", i)
 		t.Run(tag, func(t *testing.T) {
-			sc := newByteScanner(tag, true)
+			sc := newByteScanner(tag)
 			sc.feed([]byte(c.input), true)
 			tokens := sc.scanAll()
-			assert.Equal(t, c.errCount, sc.ErrorCount)
-			assert.Equal(t, c.expect, tokens)
+			assert.Equal(t, c.errCount, len(sc.Diagnostics))
+			assert.Equal(t, c.expect, tokenTexts(tokens))
 		})
 	}
 }
+
+func TestScannerPosition(t *testing.T) {
+	t.Parallel()
+
+	const input = "\xef\xbb\xbfUser-agent: *\r\n# comment\n\tDisallow: /\u00e9t\u00e9 x\rAllow: /"
+	sc := newByteScanner("robots.txt")
+	sc.feed([]byte(input), true)
+	tokens := sc.scanAll()
+	expect := []scanToken{
+		{text: "User-agent", pos: token.Position{Filename: "robots.txt", Offset: 3, Line: 1, Column: 1}},
+		{text: "*", pos: token.Position{Filename: "robots.txt", Offset: 15, Line: 1, Column: 13}},
+		{text: tokEOL, pos: token.Position{Filename: "robots.txt", Offset: 16, Line: 1, Column: 14}},
+		{text: tokEOL, pos: token.Position{Filename: "robots.txt", Offset: 18, Line: 2, Column: 1}},
+		{text: "Disallow", pos: token.Position{Filename: "robots.txt", Offset: 29, Line: 3, Column: 2}},
+		{text: "/\u00e9t\u00e9", pos: token.Position{Filename: "robots.txt", Offset: 39, Line: 3, Column: 12}},
+		{text: "x", pos: token.Position{Filename: "robots.txt", Offset: 46, Line: 3, Column: 19}},
+		{text: tokEOL, pos: token.Position{Filename: "robots.txt", Offset: 47, Line: 3, Column: 20}},
+		{text: "Allow", pos: token.Position{Filename: "robots.txt", Offset: 48, Line: 4, Column: 1}},
+		{text: "/", pos: token.Position{Filename: "robots.txt", Offset: 55, Line: 4, Column: 8}},
+	}
+	assert.Equal(t, expect, tokens)
+}
+
+func tokenTexts(tokens []scanToken) []string {
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = tok.text
+	}
+	return texts
+}
+
+func TestScannerDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	sc := newByteScanner("test")
+	sc.feed([]byte("a\xff\nb\x00\nc\xef\xbb\xbf"), true)
+	sc.scanAll()
+	require.Len(t, sc.Diagnostics, 3)
+	kinds := []DiagnosticKind{KindInvalidUTF8, KindNULByte, KindByteOrderMark}
+	lines := []int{1, 2, 3}
+	for i, d := range sc.Diagnostics {
+		assert.Equal(t, kinds[i], d.Kind)
+		assert.Equal(t, SeverityWarning, d.Severity)
+		assert.Equal(t, lines[i], d.Pos.Line)
+		assert.Equal(t, 2, d.Pos.Column)
+	}
+}
+
+func TestScannerLineTooLong(t *testing.T) {
+	t.Parallel()
+
+	long := "Disallow: /" + strings.Repeat("x", maxLineLength)
+	sc := newByteScanner("test")
+	sc.feed([]byte(long+"\nAllow: /"), true)
+	tokens := sc.scanAll()
+	require.Len(t, sc.Diagnostics, 1)
+	d := sc.Diagnostics[0]
+	assert.Equal(t, KindLineTooLong, d.Kind)
+	assert.Equal(t, maxLineLength, d.Pos.Offset)
+	assert.Equal(t, []string{"Disallow", long[10:maxLineLength], tokEOL, "Allow", "/"}, tokenTexts(tokens))
+	assert.False(t, tokens[0].truncated)
+	assert.True(t, tokens[1].truncated)
+	assert.False(t, tokens[4].truncated)
+
+	// Cut at a space after the token.
+	long = "Disallow: /" + strings.Repeat("x", maxLineLength-11) + " x"
+	sc = newByteScanner("test")
+	sc.feed([]byte(long), true)
+	tokens = sc.scanAll()
+	require.Len(t, sc.Diagnostics, 1)
+	assert.Equal(t, []string{"Disallow", long[10:maxLineLength]}, tokenTexts(tokens))
+	assert.False(t, tokens[1].truncated)
+}
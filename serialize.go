@@ -0,0 +1,103 @@
+package robotstxt
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteTo writes canonical robots.txt text of r: groups with identical
+// rules are merged, agents are sorted, paths are normalized and sitemaps
+// follow all groups. Parsing the output with the same Options gives
+// a RobotsData with the same results for all agents and paths.
+func (r *RobotsData) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	r.write(&b)
+	return b.WriteTo(w)
+}
+
+// String returns the canonical robots.txt text of r, see WriteTo.
+func (r *RobotsData) String() string {
+	var b bytes.Buffer
+	r.write(&b)
+	return b.String()
+}
+
+func (r *RobotsData) write(b *bytes.Buffer) {
+	if r.disallowAll {
+		b.WriteString("User-agent: *\nDisallow: /\n")
+		return
+	}
+
+	// Merge agents with identical groups, keeping order of first agent.
+	var keys []string
+	merged := make(map[string][]string, len(r.groups))
+	for _, a := range r.Agents() {
+		k := r.groups[a].key()
+		if _, ok := merged[k]; !ok {
+			keys = append(keys, k)
+		}
+		merged[k] = append(merged[k], a)
+	}
+
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		agents := merged[k]
+		for _, a := range agents {
+			b.WriteString("User-agent: " + a + "\n")
+		}
+		r.groups[agents[0]].writeBody(b)
+	}
+
+	if len(r.Sitemaps) > 0 || r.Host != "" {
+		if len(keys) > 0 {
+			b.WriteByte('\n')
+		}
+		for _, s := range r.Sitemaps {
+			b.WriteString("Sitemap: " + s + "\n")
+		}
+		if r.Host != "" {
+			b.WriteString("Host: " + r.Host + "\n")
+		}
+	}
+}
+
+// key identifies group content regardless of its agent.
+func (g *Group) key() string {
+	var b bytes.Buffer
+	g.writeBody(&b)
+	return b.String()
+}
+
+func (g *Group) writeBody(b *bytes.Buffer) {
+	if g.CrawlDelay != 0 {
+		b.WriteString("Crawl-delay: " + formatDelay(g.CrawlDelay) + "\n")
+	}
+	for _, r := range g.rules {
+		if r.allow {
+			b.WriteString("Allow: ")
+		} else {
+			b.WriteString("Disallow: ")
+		}
+		b.WriteString(r.path + "\n")
+	}
+	if len(g.rules) == 0 && g.CrawlDelay == 0 {
+		// Empty value still makes a group, so an agent does not fall back
+		// to "*" group.
+		b.WriteString("Disallow:\n")
+	}
+}
+
+// formatDelay returns number of seconds without losing precision.
+func formatDelay(d time.Duration) string {
+	s := strconv.FormatInt(int64(d/time.Second), 10)
+	if frac := d % time.Second; frac != 0 {
+		f := strconv.FormatInt(int64(frac)+int64(time.Second), 10)[1:]
+		s += "." + strings.TrimRight(f, "0")
+	}
+	return s
+}
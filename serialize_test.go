@@ -0,0 +1,133 @@
+package robotstxt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	t.Parallel()
+	const input = `Sitemap: http://example.com/a.xml
+User-agent: B
+Disallow: fish*
+Allow: /fish/%7eok
+crawl-delay: 1.5
+User-agent: a
+Host: example.com
+
+user-agent: c
+disallow: /fish
+allow: /fish/%7eok
+crawl-delay: 1.5
+
+User-agent: quxbot
+Disallow:
+
+User-agent: *
+Disallow: *.gif$
+Sitemap: http://example.com/b.xml
+`
+	const expect = `User-agent: *
+Disallow: *.gif$
+
+User-agent: a
+User-agent: b
+User-agent: c
+Crawl-delay: 1.5
+Disallow: /fish
+Allow: /fish/~ok
+
+User-agent: quxbot
+Disallow:
+
+Sitemap: http://example.com/a.xml
+Sitemap: http://example.com/b.xml
+Host: example.com
+`
+	r, err := FromString(input)
+	require.NoError(t, err)
+	assert.Equal(t, expect, r.String())
+
+	var b bytes.Buffer
+	n, err := r.WriteTo(&b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(expect)), n)
+	assert.Equal(t, expect, b.String())
+
+	r, err = Options{RFC9309: true}.FromString(input)
+	require.NoError(t, err)
+	assert.Contains(t, r.String(), "Allow: /fish/~ok\n")
+}
+
+func TestStringShortcuts(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "", emptyRobots.String())
+	assert.Equal(t, "", allowAll.String())
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", disallowAll.String())
+}
+
+func TestFormatDelay(t *testing.T) {
+	t.Parallel()
+	cases := map[time.Duration]string{
+		0:                        "0",
+		2 * time.Second:          "2",
+		1500 * time.Millisecond:  "1.5",
+		time.Second + 1:          "1.000000001",
+		10*time.Minute + 25*1e7:  "600.25",
+		time.Duration(3.5 * 1e9): "3.5",
+		time.Millisecond:         "0.001",
+		24 * time.Hour:           "86400",
+	}
+	for d, expect := range cases {
+		assert.Equal(t, expect, formatDelay(d))
+		r, err := FromString("User-agent: *\nCrawl-delay: " + expect)
+		require.NoError(t, err)
+		assert.Equal(t, d, r.FindGroup("*").CrawlDelay, expect)
+	}
+}
+
+// Same property as in Fuzz, checked on every corpus input and test case.
+func TestStringRoundTrip(t *testing.T) {
+	t.Parallel()
+	inputs := []string{robotsText001, robotsGoogle, robotsCaseMatching, robotsCasePrecedence, robotsTextVanityfair, robotsTextJustHTML}
+	files, err := filepath.Glob("_gofuzz/corpus/*")
+	require.NoError(t, err)
+	for _, name := range files {
+		b, err := os.ReadFile(name)
+		require.NoError(t, err)
+		inputs = append(inputs, string(b))
+	}
+
+	for _, o := range []Options{{}, {RFC9309: true}} {
+		for _, input := range inputs {
+			r, err := o.FromString(input)
+			if err != nil {
+				continue
+			}
+			text := r.String()
+			r2, err := o.FromString(text)
+			require.NoError(t, err, text)
+			require.Equal(t, text, r2.String())
+			assert.Equal(t, r.Agents(), r2.Agents())
+			assert.Equal(t, r.Sitemaps, r2.Sitemaps)
+			assert.Equal(t, r.Host, r2.Host)
+			f := func(path, agent string) bool {
+				return r.TestAgent(path, agent) == r2.TestAgent(path, agent) &&
+					r.FindGroup(agent).CrawlDelay == r2.FindGroup(agent).CrawlDelay
+			}
+			require.NoError(t, quick.Check(f, nil), text)
+			for _, agent := range r.Agents() {
+				for _, rule := range r.FindGroup(agent).Rules() {
+					assert.Equal(t, r.TestAgent(rule.Path, agent), r2.TestAgent(rule.Path, agent))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package robotstxt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrUseCached is returned, wrapped, for an HTTP status StatusPolicy maps to
+// StatusUseCached. The caller should keep using robots.txt it got before.
+var ErrUseCached = errors.New("robotstxt: keep using cached robots.txt")
+
+// StatusOutcome is what an HTTP status of robots.txt response means.
+type StatusOutcome int
+
+const (
+	// StatusDefault is the outcome of DefaultStatusPolicy.
+	StatusDefault StatusOutcome = iota
+	// StatusParse parses the body.
+	StatusParse
+	// StatusAllowAll allows everything, robots.txt is unavailable.
+	StatusAllowAll
+	// StatusDisallowAll disallows everything, robots.txt is unreachable.
+	StatusDisallowAll
+	// StatusUseCached returns an error matching ErrUseCached.
+	StatusUseCached
+	// StatusError returns an error for the unexpected status.
+	StatusError
+)
+
+var statusOutcomeNames = [...]string{
+	StatusDefault:     "default",
+	StatusParse:       "parse",
+	StatusAllowAll:    "allow-all",
+	StatusDisallowAll: "disallow-all",
+	StatusUseCached:   "use-cached",
+	StatusError:       "error",
+}
+
+func (o StatusOutcome) String() string {
+	if o >= 0 && int(o) < len(statusOutcomeNames) {
+		return statusOutcomeNames[o]
+	}
+	return "outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// StatusRule maps HTTP status codes From to To, inclusive, to Outcome.
+type StatusRule struct {
+	From, To int
+	Outcome  StatusOutcome
+}
+
+// StatusPolicy maps HTTP status codes to outcomes. The first rule covering
+// a code decides, codes no rule covers follow DefaultStatusPolicy. For
+// example, Google's handling of 429 and a stricter reading of 401 and 403:
+//
+//	StatusPolicy{
+//		{From: 401, To: 401, Outcome: StatusDisallowAll},
+//		{From: 403, To: 403, Outcome: StatusDisallowAll},
+//		{From: 429, To: 429, Outcome: StatusDisallowAll},
+//	}
+type StatusPolicy []StatusRule
+
+// DefaultStatusPolicy returns a copy of the policy of FromStatusAndBytes,
+// in line with Google's interpretation of robots.txt statuses: 2xx is
+// parsed, 4xx allows everything (even 401 and 403), 5xx disallows
+// everything as temporary unavailability. Other statuses are errors.
+func DefaultStatusPolicy() StatusPolicy {
+	return append(StatusPolicy(nil), defaultStatusPolicy...)
+}
+
+var defaultStatusPolicy = StatusPolicy{
+	{From: 200, To: 299, Outcome: StatusParse},
+
+	// From https://developers.google.com/webmasters/control-crawl-index/docs/robots_txt
+	//
+	// Google treats all 4xx errors in the same way and assumes that no valid
+	// robots.txt file exists. It is assumed that there are no restrictions.
+	// This is a "full allow" for crawling. Note: this includes 401
+	// "Unauthorized" and 403 "Forbidden" HTTP result codes.
+	{From: 400, To: 499, Outcome: StatusAllowAll},
+
+	// From Google's spec:
+	// Server errors (5xx) are seen as temporary errors that result in a "full
+	// disallow" of crawling.
+	{From: 500, To: 599, Outcome: StatusDisallowAll},
+}
+
+// Outcome returns the outcome of statusCode, never StatusDefault.
+func (p StatusPolicy) Outcome(statusCode int) StatusOutcome {
+	if outcome, ok := p.lookup(statusCode); ok && outcome != StatusDefault {
+		return outcome
+	}
+	if outcome, ok := defaultStatusPolicy.lookup(statusCode); ok {
+		return outcome
+	}
+	return StatusError
+}
+
+// lookup returns the outcome of the first rule covering statusCode.
+func (p StatusPolicy) lookup(statusCode int) (StatusOutcome, bool) {
+	for _, rule := range p {
+		if rule.From <= statusCode && statusCode <= rule.To {
+			return rule.Outcome, true
+		}
+	}
+	return StatusDefault, false
+}
+
+// fromStatus returns robots data for an outcome other than StatusParse.
+func (o Options) fromStatus(statusCode int, outcome StatusOutcome) (*RobotsData, error) {
+	switch outcome {
+	case StatusAllowAll:
+		return o.shared(allowAll)
+	case StatusDisallowAll:
+		return o.shared(disallowAll)
+	case StatusUseCached:
+		return nil, fmt.Errorf("%w: status %d", ErrUseCached, statusCode)
+	}
+	return nil, errors.New("Unexpected status: " + strconv.Itoa(statusCode))
+}
@@ -0,0 +1,124 @@
+package robotstxt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusPolicy(t *testing.T) {
+	t.Parallel()
+	policy := StatusPolicy{
+		{From: 401, To: 403, Outcome: StatusDisallowAll},
+		{From: 429, To: 429, Outcome: StatusDisallowAll},
+		{From: 400, To: 499, Outcome: StatusDefault},
+		{From: 500, To: 599, Outcome: StatusUseCached},
+		{From: 300, To: 399, Outcome: StatusAllowAll},
+		{From: 204, To: 204, Outcome: StatusError},
+		{From: 200, To: 200, Outcome: StatusAllowAll},
+	}
+	cases := []struct {
+		code          int
+		expect, deflt StatusOutcome
+	}{
+		{100, StatusError, StatusError},
+		{200, StatusAllowAll, StatusParse},
+		{204, StatusError, StatusParse},
+		{206, StatusParse, StatusParse},
+		{301, StatusAllowAll, StatusError},
+		{400, StatusAllowAll, StatusAllowAll},
+		{401, StatusDisallowAll, StatusAllowAll},
+		{403, StatusDisallowAll, StatusAllowAll},
+		{404, StatusAllowAll, StatusAllowAll},
+		{429, StatusDisallowAll, StatusAllowAll},
+		{500, StatusUseCached, StatusDisallowAll},
+		{503, StatusUseCached, StatusDisallowAll},
+		{600, StatusError, StatusError},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expect, policy.Outcome(c.code), "%d", c.code)
+		assert.Equal(t, c.deflt, StatusPolicy(nil).Outcome(c.code), "%d", c.code)
+		assert.Equal(t, c.deflt, DefaultStatusPolicy().Outcome(c.code), "%d", c.code)
+	}
+
+	// The default policy is a copy, changing it affects nothing else.
+	deflt := DefaultStatusPolicy()
+	deflt[0].Outcome = StatusError
+	assert.Equal(t, StatusParse, StatusPolicy(nil).Outcome(200))
+	assert.Equal(t, StatusParse, DefaultStatusPolicy().Outcome(200))
+}
+
+func TestStatusPolicyOptions(t *testing.T) {
+	t.Parallel()
+	o := Options{StatusPolicy: StatusPolicy{
+		{From: 401, To: 403, Outcome: StatusDisallowAll},
+		{From: 429, To: 429, Outcome: StatusDisallowAll},
+		{From: 500, To: 599, Outcome: StatusUseCached},
+		{From: 300, To: 399, Outcome: StatusAllowAll},
+	}}
+	for _, c := range []struct {
+		code  int
+		allow bool
+	}{
+		{200, false},
+		{301, true},
+		{401, false},
+		{403, false},
+		{404, true},
+		{429, false},
+	} {
+		r, err := o.FromStatusAndString(c.code, "User-agent: *\nDisallow: /\n")
+		require.NoError(t, err, c.code)
+		expectAccess(t, r, c.allow, "/", "bot")
+
+		r, err = o.FromResponse(newHttpResponse(c.code, "User-agent: *\nDisallow: /\n"))
+		require.NoError(t, err, c.code)
+		expectAccess(t, r, c.allow, "/", "bot")
+	}
+
+	r, err := o.FromStatusAndString(503, "")
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, ErrUseCached), "%v", err)
+	_, err = o.FromResponse(newHttpResponse(500, ""))
+	assert.True(t, errors.Is(err, ErrUseCached), "%v", err)
+
+	_, err = o.FromStatusAndString(100, "")
+	assert.EqualError(t, err, "Unexpected status: 100")
+	_, err = FromStatusAndString(301, "")
+	assert.EqualError(t, err, "Unexpected status: 301")
+}
+
+func TestStatusPolicyFetch(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var f Fetcher
+	r, err := f.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, true, "/", "bot")
+
+	f.Options.StatusPolicy = StatusPolicy{{From: 429, To: 429, Outcome: StatusDisallowAll}}
+	r, err = f.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	expectAccess(t, r, false, "/", "bot")
+
+	f.Options.StatusPolicy = StatusPolicy{{From: 429, To: 429, Outcome: StatusUseCached}}
+	r, err = f.Fetch(context.Background(), server.URL)
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, ErrUseCached), "%v", err)
+}
+
+func TestStatusOutcomeString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "use-cached", StatusUseCached.String())
+	assert.Equal(t, "allow-all", StatusAllowAll.String())
+	assert.Equal(t, "outcome(42)", StatusOutcome(42).String())
+}
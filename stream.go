@@ -0,0 +1,226 @@
+package robotstxt
+
+import (
+	"bytes"
+	"go/token"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// streamChunkSize is the size of reads by FromReader.
+const streamChunkSize = 32 << 10
+
+// longLineHead is how much of a line longer than maxLineLength FromReader
+// keeps, the scanner ignores the rest. It covers byte order mark and the
+// last rune crossing the limit.
+const longLineHead = len("\ufeff") + maxLineLength + utf8.UTFMax
+
+// FromReader is Options.FromReader with default options.
+func FromReader(rd io.Reader) (*RobotsData, error) {
+	return Options{}.FromReader(rd)
+}
+
+// FromReader is FromBytes for content read from rd, with the same result.
+// Content is parsed while it is read, memory taken besides the result does
+// not depend on content size. It does not close rd.
+func (o Options) FromReader(rd io.Reader) (*RobotsData, error) {
+	origin, err := o.origin()
+	if err != nil {
+		return nil, err
+	}
+	s := newStreamParser(o)
+	if s.max >= 0 {
+		// One more byte tells that content was truncated.
+		rd = io.LimitReader(rd, int64(s.max)+1)
+	}
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, err := rd.Read(buf)
+		if n > 0 {
+			s.write(buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.close(origin)
+}
+
+// streamParser feeds the scanner and the parser with whole lines of content
+// written in arbitrary pieces.
+type streamParser struct {
+	o      Options
+	max    int // Options.MaxSize, negative for no limit
+	sc     *byteScanner
+	parser *parser
+	tokens bool // scanner found a token
+	space  spaceChecker
+	n      int // bytes written
+
+	// pending is content not scanned yet, starting at a line start at
+	// offset base. A line longer than longLineHead is cut: its ignored
+	// gapLen bytes at pending[gapAt:] are dropped.
+	pending []byte
+	base    int
+	gapAt   int
+	gapLen  int
+}
+
+func newStreamParser(o Options) *streamParser {
+	s := &streamParser{
+		o:      o,
+		max:    o.maxSize(),
+		sc:     newByteScanner(o.filename()),
+		parser: newParser(nil),
+	}
+	s.sc.pos.Line = 1
+	s.parser.rfc9309 = o.RFC9309
+	return s
+}
+
+func (s *streamParser) write(data []byte) {
+	s.n += len(data)
+	s.space.write(data)
+	if s.gapLen > 0 && len(s.pending) == s.gapAt {
+		// Still in the ignored part of a long line.
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			s.gapLen += len(data)
+			return
+		}
+		s.gapLen += i
+		data = data[i:]
+	}
+	// Without a new line break there is nothing new to scan, unless
+	// pending ends with "\r" that might have been the first half of "\r\n".
+	newLine := bytes.IndexAny(data, "\r\n") >= 0 || len(s.pending) > 0 && s.pending[len(s.pending)-1] == '\r'
+	s.pending = append(s.pending, data...)
+	if newLine {
+		s.flush(s.cut(false), false)
+	}
+
+	if s.gapLen == 0 && len(s.pending) > longLineHead {
+		end := bytes.IndexAny(s.pending, "\r\n")
+		if end < 0 {
+			end = len(s.pending)
+		}
+		if end > longLineHead {
+			s.gapAt, s.gapLen = longLineHead, end-longLineHead
+			s.pending = append(s.pending[:longLineHead], s.pending[end:]...)
+		}
+	}
+}
+
+func (s *streamParser) truncated() bool {
+	return s.max >= 0 && s.n > s.max
+}
+
+// cut returns the length of pending to scan: complete lines within
+// the size limit.
+func (s *streamParser) cut(eof bool) int {
+	if eof && !s.truncated() {
+		return len(s.pending)
+	}
+	for i := len(s.pending) - 1; i >= 0; i-- {
+		c := s.pending[i]
+		if c != '\n' && c != '\r' {
+			continue
+		}
+		end := i + 1
+		if c == '\r' && (end == len(s.pending) && !eof || end < len(s.pending) && s.pending[end] == '\n') {
+			// "\r\n" is a single line break.
+			continue
+		}
+		offset := s.base + end
+		if s.gapLen > 0 && end > s.gapAt {
+			offset += s.gapLen
+		}
+		if s.max >= 0 && offset > s.max {
+			continue
+		}
+		return end
+	}
+	return 0
+}
+
+// flush scans pending[:n], end tells it is the last content.
+func (s *streamParser) flush(n int, end bool) {
+	if n == 0 && !end {
+		return
+	}
+	chunk := s.pending[:n]
+	if s.gapLen > 0 && n > s.gapAt {
+		s.scan(chunk[:s.gapAt], s.base, false)
+		s.scan(chunk[s.gapAt:], s.base+s.gapAt+s.gapLen, end)
+		s.base += s.gapLen
+		s.gapAt, s.gapLen = 0, 0
+	} else {
+		s.scan(chunk, s.base, end)
+	}
+	s.base += n
+	s.pending = append(s.pending[:0], s.pending[n:]...)
+}
+
+func (s *streamParser) scan(chunk []byte, base int, end bool) {
+	s.sc.resume(chunk, base, end)
+	tokens := s.sc.scanAll()
+	if len(tokens) > 0 {
+		s.tokens = true
+	}
+	s.parser.feed(tokens, end)
+}
+
+// close scans the rest of content and returns the result.
+func (s *streamParser) close(origin string) (*RobotsData, error) {
+	s.flush(s.cut(true), true)
+	var truncated *Diagnostic
+	if s.truncated() {
+		pos := token.Position{Filename: s.o.filename(), Offset: s.base, Line: s.sc.pos.Line, Column: 1}
+		truncated = newTruncatedDiagnostic(pos)
+	} else if s.space.space() || !s.tokens && len(s.sc.Diagnostics) == 0 {
+		// Same special cases as in FromBytes.
+		return s.o.shared(emptyRobots)
+	}
+	return s.o.build(origin, s.parser, s.sc.Diagnostics, truncated)
+}
+
+// spaceChecker tells whether content written in pieces is all white space,
+// as bytes.TrimSpace sees it.
+type spaceChecker struct {
+	carry    []byte // incomplete rune at the end of the last piece
+	nonSpace bool
+}
+
+func (c *spaceChecker) write(p []byte) {
+	for len(p) > 0 && !c.nonSpace {
+		var r rune
+		switch {
+		case len(c.carry) > 0:
+			c.carry = append(c.carry, p[0])
+			p = p[1:]
+			if !utf8.FullRune(c.carry) {
+				continue
+			}
+			r, _ = utf8.DecodeRune(c.carry)
+			c.carry = c.carry[:0]
+		case !utf8.FullRune(p):
+			c.carry = append(c.carry, p...)
+			return
+		default:
+			var w int
+			r, w = utf8.DecodeRune(p)
+			p = p[w:]
+		}
+		if !unicode.IsSpace(r) {
+			c.nonSpace = true
+		}
+	}
+}
+
+func (c *spaceChecker) space() bool {
+	return !c.nonSpace && len(c.carry) == 0
+}
@@ -0,0 +1,146 @@
+package robotstxt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamInputs are robots.txt contents with lines, comments and encoding
+// problems of every kind.
+var streamInputs = []string{
+	"",
+	" \n\t\r\n",
+	"  ",
+	" \xe2\x80",
+	"# only comment",
+	"# comment\n",
+	"\xef\xbb\xbfUser-agent: *\r\nDisallow: /a # comment\r\nAllow: /a/b\r\n",
+	"User-agent: a\rDisallow: /x\r\rUser-agent: b\r\nDisallow:\nCrawl-delay: 2",
+	"User-agent: bot\n# comment\n\n\nDisallow: /caf\xc3\xa9\nAllow: /\xff\x00\n\xef\xbb\xbfSitemap: http://example.com/s.xml\nHost: example.com",
+	"Disallow: /orphan\nUser-agent: bot\nCrawl-delay: abc\nDisallow: /private\nUser-agent",
+	"User-agent: quxbot\n",
+	robotsText001,
+	robotsTextVanityfair,
+}
+
+// longLineInputs have lines longer than maxLineLength.
+var longLineInputs = []string{
+	"User-agent: *\nDisallow: /" + strings.Repeat("x", maxLineLength+100) + "\nAllow: /y\n",
+	"User-agent: *\nDisallow: /" + strings.Repeat("x", maxLineLength-11) + " /y\nAllow: /y\n",
+	"User-agent: *\r\nDisallow:" + strings.Repeat(" ", 2*maxLineLength) + "\r\nAllow: /y",
+	"\xef\xbb\xbfUser-agent: *\nDisallow: /" + strings.Repeat("é", maxLineLength) + "\nAllow: /" + strings.Repeat("z", maxLineLength),
+	strings.Repeat(" ", maxLineLength+10),
+	"User-agent: *\n# " + strings.Repeat("comment ", maxLineLength/4) + "\nDisallow: /c",
+}
+
+func TestFromReader(t *testing.T) {
+	t.Parallel()
+	for _, o := range []Options{{}, {Lenient: true}, {Lenient: true, RFC9309: true}} {
+		for _, input := range streamInputs {
+			expect, expectErr := o.FromString(input)
+			// Cut input at every byte offset.
+			for i := 0; i <= len(input); i++ {
+				rd := io.MultiReader(strings.NewReader(input[:i]), strings.NewReader(input[i:]))
+				r, err := o.FromReader(rd)
+				requireSameRobots(t, expect, expectErr, r, err, "%q cut at %d", input, i)
+			}
+			r, err := o.FromReader(iotest.OneByteReader(strings.NewReader(input)))
+			requireSameRobots(t, expect, expectErr, r, err, "%q one byte at a time", input)
+		}
+	}
+}
+
+func TestFromReaderLongLines(t *testing.T) {
+	t.Parallel()
+	o := Options{Lenient: true}
+	for _, input := range longLineInputs {
+		expect, expectErr := o.FromString(input)
+		require.NoError(t, expectErr)
+		for i := 0; i <= len(input); i += 997 {
+			rd := io.MultiReader(strings.NewReader(input[:i]), strings.NewReader(input[i:]))
+			r, err := o.FromReader(rd)
+			requireSameRobots(t, expect, expectErr, r, err, "input %d cut at %d", len(input), i)
+		}
+		r, err := o.FromReader(iotest.OneByteReader(strings.NewReader(input)))
+		requireSameRobots(t, expect, expectErr, r, err, "input %d one byte at a time", len(input))
+	}
+}
+
+func TestFromReaderMaxSize(t *testing.T) {
+	t.Parallel()
+	inputs := append([]string{"User-agent: *\r\nDisallow: /a\r\nDisallow: /b\r\n", "User-agent: *\rDisallow: /a\r\r"}, longLineInputs...)
+	for _, input := range inputs {
+		sizes := []int{-1, 0, 1, len(input) - 1, len(input), len(input) + 1}
+		for i := 10; i < len(input) && i < 100; i++ {
+			sizes = append(sizes, i)
+		}
+		for _, size := range sizes {
+			o := Options{MaxSize: size, Lenient: true}
+			if size == 0 {
+				o.MaxSize = -2
+			}
+			expect, expectErr := o.FromString(input)
+			for _, rd := range []io.Reader{strings.NewReader(input), iotest.HalfReader(strings.NewReader(input))} {
+				r, err := o.FromReader(rd)
+				requireSameRobots(t, expect, expectErr, r, err, "input %d max size %d", len(input), size)
+			}
+		}
+	}
+}
+
+// TestFromReaderMemory checks that FromReader keeps at most a few lines
+// of content in memory.
+func TestFromReaderMemory(t *testing.T) {
+	t.Parallel()
+	s := newStreamParser(Options{MaxSize: -1})
+	s.write([]byte("User-agent: *\n"))
+	line := []byte("Disallow: /" + strings.Repeat("x", 1000) + "\n")
+	for i := 0; i < 100; i++ {
+		s.write(line)
+		assert.Empty(t, s.pending)
+	}
+	long := bytes.Repeat([]byte("y"), 1000)
+	for i := 0; i < 1000; i++ {
+		s.write(long)
+		assert.True(t, len(s.pending) <= longLineHead, "pending %d", len(s.pending))
+	}
+	s.write([]byte("\nAllow: /z"))
+	r, err := s.close("")
+	require.NoError(t, err)
+	expectAccess(t, r, false, string(line[len("Disallow: "):len(line)-1]), "bot")
+	expectAccess(t, r, true, "/y", "bot")
+	assert.Len(t, r.Warnings, 1)
+}
+
+func TestFromReaderError(t *testing.T) {
+	t.Parallel()
+	failure := errors.New("connection reset")
+	r, err := FromReader(io.MultiReader(strings.NewReader("User-agent: *\n"), iotest.ErrReader(failure)))
+	assert.Nil(t, r)
+	assert.Equal(t, failure, err)
+}
+
+func requireSameRobots(t *testing.T, expect *RobotsData, expectErr error, r *RobotsData, err error, msgAndArgs ...interface{}) {
+	t.Helper()
+	if expectErr != nil {
+		require.Error(t, err, msgAndArgs...)
+		require.Equal(t, expectErr.Error(), err.Error(), msgAndArgs...)
+		return
+	}
+	require.NoError(t, err, msgAndArgs...)
+	require.Equal(t, expect == emptyRobots, r == emptyRobots, msgAndArgs...)
+	expectJSON, err := json.Marshal(expect)
+	require.NoError(t, err)
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	require.Equal(t, string(expectJSON), string(data), msgAndArgs...)
+	require.Equal(t, expect.Warnings, r.Warnings, msgAndArgs...)
+}
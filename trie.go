@@ -0,0 +1,311 @@
+package robotstxt
+
+import "sort"
+
+// Compile builds an index of rules of every group, so Test and TestAgent
+// take time proportional to the length of path instead of the number of
+// rules. Literal rules are indexed in a trie, wildcard rules also by a
+// part they require in an automaton; only wildcard rules with both their
+// first part and that part in path are checked. Results are the same.
+// It pays off with many rules or many tested paths.
+//
+// Compile modifies r, call it before r is used concurrently. Options.Compile
+// does it while parsing. Decoded JSON and binary data is not compiled.
+func (r *RobotsData) Compile() {
+	for _, g := range r.groups {
+		g.index = newRuleIndex(g)
+	}
+	for _, g := range r.tokens {
+		if g.index == nil {
+			g.index = newRuleIndex(g)
+		}
+	}
+}
+
+// ruleIndex finds the same rule as Group.matchRules in time proportional
+// to the length of path, see RobotsData.Compile.
+//
+// Literal rules are stored in a byte trie by path: the nodes along the
+// tested path are exactly the matching rules, the deepest one is the
+// longest. Wildcard rules are stored in the node of their literal part
+// before the first "*", only those reached by the path are candidates.
+// Among them, a rule with a key part, see keyPart, is a candidate only if
+// the key is found in path. Keys of all rules are searched at once by an
+// Aho-Corasick automaton, so rules like "/*.gif$" and "/*.jpg$" sharing
+// the first part are not checked one by one.
+type ruleIndex struct {
+	root trieNode
+	// slash is the best "/" rule, it matches any path with length 1.
+	slash      *rankedRule
+	precedence Precedence
+	// keys is the automaton of key parts, nil without them.
+	keys *trieNode
+}
+
+type trieNode struct {
+	labels []byte // sorted
+	next   []*trieNode
+	// best literal rule with the path of this node.
+	best *rankedRule
+	// globs whose first literal part ends at this node and without a key
+	// part, longest first.
+	globs []*rankedRule
+	// keyed are the other globs of this node by key, longest first.
+	keyed map[int][]*rankedRule
+
+	// In the automaton of keys: fail is the node of the longest proper
+	// suffix of this node path, dict is the nearest node on the fail chain
+	// ending keys, ids are keys ending at this node.
+	fail, dict *trieNode
+	ids        []int
+}
+
+// rankedRule is a rule with its position in the group for precedence
+// between equal matches. For wildcard rules, pattern matches length
+// octets: it is either the rule pattern or the implied one.
+type rankedRule struct {
+	*rule
+	index   int
+	pattern *glob
+	length  int
+}
+
+func newRuleIndex(g *Group) *ruleIndex {
+	x := &ruleIndex{precedence: g.precedence}
+	ids := make(map[string]int)
+	for i, r := range g.rules {
+		rr := &rankedRule{rule: r, index: i}
+		switch {
+		case r.pattern != nil:
+			x.insertGlob(ids, &rankedRule{rule: r, index: i, pattern: r.pattern, length: len(r.path)})
+		case r.path == "/":
+			if x.outranks(rr, 1, x.slash, 1) {
+				x.slash = rr
+			}
+		default:
+			n := x.root.insert(r.path)
+			if x.outranks(rr, 0, n.best, 0) {
+				n.best = rr
+			}
+		}
+		if r.implied != nil {
+			x.insertGlob(ids, &rankedRule{rule: r, index: i, pattern: r.implied, length: r.implied.n})
+		}
+	}
+	x.root.sortGlobs()
+	if len(ids) > 0 {
+		x.keys = &trieNode{}
+		for part, id := range ids {
+			n := x.keys.insert(part)
+			n.ids = append(n.ids, id)
+		}
+		x.keys.link()
+	}
+	return x
+}
+
+// keyPart returns the literal part of g that a matching path must contain,
+// the most selective one: the last part if g is anchored, it must end
+// path, otherwise the longest part after the first one. It is empty if
+// there are only empty parts after the first one.
+func keyPart(g *glob) (part string, anchored bool) {
+	last := g.parts[len(g.parts)-1]
+	if g.anchored && last != "" && len(g.parts) > 1 {
+		return last, true
+	}
+	for _, p := range g.parts[1:] {
+		if len(p) > len(part) {
+			part = p
+		}
+	}
+	return part, false
+}
+
+// insertGlob adds g to the node of its first part, ids numbers key parts.
+// Keys are numbered twice the part id, plus one if the part must end path.
+func (x *ruleIndex) insertGlob(ids map[string]int, g *rankedRule) {
+	n := x.root.insert(g.pattern.parts[0])
+	part, anchored := keyPart(g.pattern)
+	if part == "" {
+		n.globs = append(n.globs, g)
+		return
+	}
+	id, ok := ids[part]
+	if !ok {
+		id = len(ids)
+		ids[part] = id
+	}
+	key := 2 * id
+	if anchored {
+		key++
+	}
+	if n.keyed == nil {
+		n.keyed = make(map[int][]*rankedRule)
+	}
+	n.keyed[key] = append(n.keyed[key], g)
+}
+
+// find returns the rule deciding path, nil if none matches.
+func (x *ruleIndex) find(path string) *rule {
+	best, bestLen := x.slash, 1
+	if best == nil {
+		bestLen = 0
+	}
+	// Literal rules: every node along path with a rule.
+	n := &x.root
+	for i := 0; i < len(path); i++ {
+		if n = n.child(path[i]); n == nil {
+			break
+		}
+		if n.best != nil && x.outranks(n.best, i+1, best, bestLen) {
+			best, bestLen = n.best, i+1
+		}
+	}
+	// Wildcard rules reached by path, with their key in path.
+	var buf [16]int
+	keys := x.findKeys(path, buf[:0])
+	n = &x.root
+	for i := 0; n != nil; i++ {
+		best, bestLen = x.findGlobs(n.globs, path, best, bestLen)
+		if n.keyed != nil {
+			for _, key := range keys {
+				best, bestLen = x.findGlobs(n.keyed[key], path, best, bestLen)
+			}
+		}
+		if i == len(path) {
+			break
+		}
+		n = n.child(path[i])
+	}
+	if best == nil {
+		return nil
+	}
+	return best.rule
+}
+
+// findGlobs returns the best of best and globs matching path. When longer
+// match always wins, it skips those too short.
+func (x *ruleIndex) findGlobs(globs []*rankedRule, path string, best *rankedRule, bestLen int) (*rankedRule, int) {
+	lengthFirst := x.precedence.lengthFirst()
+	for _, g := range globs {
+		if lengthFirst && g.length < bestLen {
+			break
+		}
+		if x.outranks(g, g.length, best, bestLen) && g.pattern.match(path) {
+			best, bestLen = g, g.length
+		}
+	}
+	return best, bestLen
+}
+
+// findKeys appends to keys those found in path, see insertGlob, once each.
+func (x *ruleIndex) findKeys(path string, keys []int) []int {
+	if x.keys == nil {
+		return keys
+	}
+	add := func(key int) {
+		for _, k := range keys {
+			if k == key {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+	n := x.keys
+	for i := 0; i < len(path); i++ {
+		n = n.step(x.keys, path[i])
+		for m := n; m != nil; m = m.dict {
+			for _, id := range m.ids {
+				add(2 * id)
+				if i == len(path)-1 {
+					add(2*id + 1)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func (x *ruleIndex) outranks(r *rankedRule, l int, best *rankedRule, bestLen int) bool {
+	if best == nil {
+		return true
+	}
+	return x.precedence.outranks(r.rule, r.index, l, best.rule, best.index, bestLen)
+}
+
+func (n *trieNode) child(c byte) *trieNode {
+	i := sort.Search(len(n.labels), func(i int) bool { return n.labels[i] >= c })
+	if i < len(n.labels) && n.labels[i] == c {
+		return n.next[i]
+	}
+	return nil
+}
+
+func (n *trieNode) insert(key string) *trieNode {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		next := n.child(c)
+		if next == nil {
+			next = &trieNode{}
+			j := sort.Search(len(n.labels), func(j int) bool { return n.labels[j] >= c })
+			n.labels = append(n.labels, 0)
+			copy(n.labels[j+1:], n.labels[j:])
+			n.labels[j] = c
+			n.next = append(n.next, nil)
+			copy(n.next[j+1:], n.next[j:])
+			n.next[j] = next
+		}
+		n = next
+	}
+	return n
+}
+
+func (n *trieNode) sortGlobs() {
+	byLength := func(globs []*rankedRule) {
+		sort.SliceStable(globs, func(i, j int) bool {
+			return globs[i].length > globs[j].length
+		})
+	}
+	byLength(n.globs)
+	for _, globs := range n.keyed {
+		byLength(globs)
+	}
+	for _, next := range n.next {
+		next.sortGlobs()
+	}
+}
+
+// link sets fail and dict links of the automaton rooted at n, breadth first
+// so links of shorter paths are ready.
+func (n *trieNode) link() {
+	queue := []*trieNode{n}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for i, c := range p.labels {
+			next := p.next[i]
+			next.fail = n
+			if p != n {
+				next.fail = p.fail.step(n, c)
+			}
+			next.dict = next.fail
+			if len(next.dict.ids) == 0 {
+				next.dict = next.dict.dict
+			}
+			queue = append(queue, next)
+		}
+	}
+}
+
+// step returns the automaton node after n on c, root is the automaton root.
+func (n *trieNode) step(root *trieNode, c byte) *trieNode {
+	for {
+		if next := n.child(c); next != nil {
+			return next
+		}
+		if n == root {
+			return root
+		}
+		n = n.fail
+	}
+}
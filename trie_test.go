@@ -0,0 +1,172 @@
+package robotstxt
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	for _, o := range []Options{{Compile: true}, {Compile: true, RFC9309: true}} {
+		r, err := o.FromString(robotsGoogle)
+		require.NoError(t, err)
+		plain, err := Options{RFC9309: o.RFC9309}.FromString(robotsGoogle)
+		require.NoError(t, err)
+		for _, a := range r.Agents() {
+			require.NotNil(t, r.FindGroup(a).index, a)
+			for _, rule := range plain.FindGroup(a).Rules() {
+				for _, p := range []string{rule.Path, rule.Path + "x", rule.Text, "/"} {
+					assert.Equal(t, plain.TestAgent(p, a), r.TestAgent(p, a), "agent %q path %q", a, p)
+				}
+			}
+		}
+	}
+
+	r, err := Options{Compile: true}.NewBuilder().Group("a").Disallow("/x").Allow("/x/*.gif").Build()
+	require.NoError(t, err)
+	require.NotNil(t, r.FindGroup("a").index)
+	expectAccess(t, r, false, "/x/a.png", "a")
+	expectAccess(t, r, true, "/x/a.gif", "a")
+}
+
+func TestCompileManyWildcards(t *testing.T) {
+	t.Parallel()
+	robots := robotsManyWildcards + "Allow: /*.ext1*.bak\nAllow: /keep/*.ext1$\nDisallow: /*s*e\n"
+	r, err := Options{Compile: true}.FromString(robots)
+	require.NoError(t, err)
+	plain, err := FromString(robots)
+	require.NoError(t, err)
+	paths := append([]string{"/a.ext1", "/keep/a.ext1", "/x.ext12.bak", "/x.ext12.bak.ext7", "/see", "/s"}, benchmarkManyWildcardsPaths...)
+	for _, p := range paths {
+		assert.Equal(t, plain.TestAgent(p, "bot"), r.TestAgent(p, "bot"), p)
+	}
+	expectAccess(t, r, false, "/a.ext1999", "bot")
+	expectAccess(t, r, true, "/a.ext1999x", "bot")
+}
+
+// TestCompileRandom compares compiled index with linear scan of rules.
+func TestCompileRandom(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	str := func(alphabet string, n int) string {
+		b := make([]byte, rnd.Intn(n))
+		for i := range b {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	for i := 0; i < 2000; i++ {
+		var b strings.Builder
+		b.WriteString("User-agent: *\n")
+		for j := rnd.Intn(12); j >= 0; j-- {
+			key := "Disallow"
+			if rnd.Intn(2) == 0 {
+				key = "Allow"
+			}
+			value := str("/ab*$", 6)
+			if rnd.Intn(8) == 0 {
+				value += "/index.html"
+			}
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+		for _, o := range []Options{{Lenient: true}, {Lenient: true, RFC9309: true}} {
+			o.Precedence = precedences[i%len(precedences)]
+			r, err := o.FromString(b.String())
+			require.NoError(t, err)
+			g := r.FindGroup("bot")
+			index := newRuleIndex(g)
+			for j := 0; j < 50; j++ {
+				path := str("/ab$", 8)
+				if rnd.Intn(8) == 0 {
+					path += "/"
+				}
+				expect, _ := g.matchRules(path, false)
+				require.Equal(t, expect, index.find(path), "%v robots.txt:\n%spath %q", o.Precedence, b.String(), path)
+			}
+		}
+	}
+}
+
+// robotsManyRules has thousands of Disallow lines.
+var robotsManyRules = func() string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&b, "Disallow: /catalog/item-%d/\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "Allow: /catalog/item-%d/*.jpg$\n", i)
+		}
+	}
+	return b.String()
+}()
+
+var benchmarkManyRulesPaths = []string{
+	"/catalog/item-1500/details",
+	"/catalog/item-1500/photo.jpg",
+	"/catalog/item-99999/details",
+	"/about",
+}
+
+// robotsManyWildcards has thousands of wildcard rules sharing the first
+// literal part.
+var robotsManyWildcards = func() string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "Disallow: /*.ext%d$\n", i)
+	}
+	return b.String()
+}()
+
+var benchmarkManyWildcardsPaths = []string{
+	"/files/report.ext1500",
+	"/files/report.ext1500.bak",
+	"/files/report.pdf",
+	"/",
+}
+
+func BenchmarkTestManyRules(b *testing.B) {
+	benchmarkTestManyRules(b, Options{}, robotsManyRules, benchmarkManyRulesPaths)
+}
+
+func BenchmarkTestManyRulesCompiled(b *testing.B) {
+	benchmarkTestManyRules(b, Options{Compile: true}, robotsManyRules, benchmarkManyRulesPaths)
+}
+
+func BenchmarkTestManyWildcards(b *testing.B) {
+	benchmarkTestManyRules(b, Options{}, robotsManyWildcards, benchmarkManyWildcardsPaths)
+}
+
+func BenchmarkTestManyWildcardsCompiled(b *testing.B) {
+	benchmarkTestManyRules(b, Options{Compile: true}, robotsManyWildcards, benchmarkManyWildcardsPaths)
+}
+
+func benchmarkTestManyRules(b *testing.B, o Options, robots string, paths []string) {
+	r, err := o.FromString(robots)
+	if err != nil {
+		b.Fatal(err)
+	}
+	g := r.FindGroup("bot")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Test(paths[i%len(paths)])
+	}
+}
+
+func BenchmarkCompileManyRules(b *testing.B) {
+	r, err := FromString(robotsManyRules)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Compile()
+	}
+}
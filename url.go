@@ -0,0 +1,109 @@
+package robotstxt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrOriginMismatch is returned by TestURL for a URL of another origin than
+// robots.txt applies to. Such URL is neither allowed nor disallowed by it.
+var ErrOriginMismatch = errors.New("robotstxt: URL origin does not match robots.txt origin")
+
+// Origin returns scheme://host[:port] robots.txt applies to, see
+// Options.Origin. It is empty when unknown.
+func (r *RobotsData) Origin() string {
+	return r.origin
+}
+
+// ETag returns ETag header of the response robots.txt came from,
+// see FromResponse. It is empty when unknown.
+func (r *RobotsData) ETag() string {
+	return r.etag
+}
+
+// LastModified returns Last-Modified header of the response robots.txt
+// came from, see FromResponse. It is empty when unknown.
+func (r *RobotsData) LastModified() string {
+	return r.lastModified
+}
+
+// TestURL is TestAgent for an absolute URL or a reference relative to the
+// origin of robots.txt. Path and query of u are tested, empty path as "/",
+// the fragment is ignored. When origin of r is known, an absolute or
+// scheme-relative URL of another scheme, host or port is an error matching
+// ErrOriginMismatch. Nil u is an error.
+func (r *RobotsData) TestURL(u *url.URL, agent string) (bool, error) {
+	if u == nil {
+		return false, errors.New("robotstxt: nil URL")
+	}
+	if u.Opaque != "" {
+		return false, fmt.Errorf("robotstxt: URL %q has no path", u.String())
+	}
+	if r.origin != "" && (u.Scheme != "" || u.Host != "") {
+		abs := *u
+		if abs.Scheme == "" {
+			// "//host/path" takes the scheme of robots.txt.
+			abs.Scheme, _, _ = strings.Cut(r.origin, "://")
+		}
+		if o := urlOrigin(&abs); o != r.origin {
+			return false, fmt.Errorf("%w: %s is not %s", ErrOriginMismatch, o, r.origin)
+		}
+	}
+	return r.TestAgent(urlPath(u), agent), nil
+}
+
+// TestURLString is TestURL for a URL string.
+func (r *RobotsData) TestURLString(rawURL, agent string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, err
+	}
+	return r.TestURL(u, agent)
+}
+
+// urlPath returns the part of u robots.txt rules are matched against.
+// From RFC 9309:
+// The URI path includes the query component, if present.
+func urlPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.ForceQuery || u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// defaultPorts are omitted from origins.
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21"}
+
+// urlOrigin returns scheme://host[:port] of u in lower case, without
+// default port of the scheme.
+func urlOrigin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+	if port != "" || strings.Contains(host, ":") {
+		host = net.JoinHostPort(host, port)
+		host = strings.TrimSuffix(host, ":")
+	}
+	return scheme + "://" + host
+}
+
+// parseOrigin returns origin of rawURL, an absolute URL.
+func parseOrigin(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("robotstxt: origin %q is not an absolute URL", rawURL)
+	}
+	return urlOrigin(u), nil
+}
@@ -0,0 +1,144 @@
+package robotstxt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestURL(t *testing.T) {
+	t.Parallel()
+	const robots = "User-agent: *\nDisallow: /private\nDisallow: /*?session=\nDisallow: /$\nAllow: /?\n"
+	r, err := Options{Origin: "https://Example.COM:443/robots.txt"}.FromString(robots)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", r.Origin())
+
+	cases := []struct {
+		url   string
+		allow bool
+	}{
+		{"https://example.com/public", true},
+		{"https://example.com/private/page", false},
+		{"https://EXAMPLE.com:443/private", false},
+		{"https://example.com/page?session=1", false},
+		{"https://example.com/page#?session=1", true},
+		{"https://example.com", false},
+		{"https://example.com?", true},
+		{"https://example.com/?q", true},
+		{"/private#top", false},
+		{"public?x=1", true},
+		{"//example.com/private", false},
+		{"//Example.com:443/public", true},
+	}
+	for _, c := range cases {
+		allow, err := r.TestURLString(c.url, "bot")
+		require.NoError(t, err, c.url)
+		assert.Equal(t, c.allow, allow, c.url)
+	}
+
+	for _, other := range []string{"http://example.com/", "https://www.example.com/", "https://example.com:8443/", "//example.org/"} {
+		_, err := r.TestURLString(other, "bot")
+		assert.True(t, errors.Is(err, ErrOriginMismatch), "%s: %v", other, err)
+	}
+	_, err = r.TestURLString("mailto:a@example.com", "bot")
+	assert.Error(t, err)
+	_, err = r.TestURLString("https://example.com/%zz", "bot")
+	assert.Error(t, err)
+	_, err = r.TestURL(nil, "bot")
+	assert.Error(t, err)
+
+	// Without origin any URL is accepted.
+	r, err = FromString(robots)
+	require.NoError(t, err)
+	allow, err := r.TestURL(&url.URL{Scheme: "http", Host: "other.org", Path: "/private"}, "bot")
+	require.NoError(t, err)
+	assert.False(t, allow)
+	allow, err = r.TestURLString("//other.org/public", "bot")
+	require.NoError(t, err)
+	assert.True(t, allow)
+	_, err = r.TestURL(nil, "bot")
+	assert.Error(t, err)
+}
+
+func TestURLOrigin(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"http://example.com":          "http://example.com",
+		"HTTP://Example.com:80/a":     "http://example.com",
+		"http://example.com:8080":     "http://example.com:8080",
+		"https://example.com:80":      "https://example.com:80",
+		"http://[::1]/robots.txt":     "http://[::1]",
+		"http://[::1]:80/robots.txt":  "http://[::1]",
+		"http://[::1]:81/robots.txt":  "http://[::1]:81",
+		"ftp://user@example.com:21/x": "ftp://example.com",
+	}
+	for in, expect := range cases {
+		origin, err := parseOrigin(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expect, origin, in)
+	}
+	for _, in := range []string{"/robots.txt", "example.com", "://x"} {
+		_, err := parseOrigin(in)
+		assert.Error(t, err, in)
+	}
+
+	_, err := Options{Origin: "example.com"}.FromString("User-agent: *\nDisallow: /\n")
+	assert.Error(t, err)
+	_, err = Options{Origin: "/"}.NewBuilder().Group("*").Disallow("/").Build()
+	assert.Error(t, err)
+}
+
+func TestOriginShared(t *testing.T) {
+	t.Parallel()
+	o := Options{Origin: "http://example.com"}
+	for _, c := range []struct {
+		status int
+		body   string
+		shared *RobotsData
+	}{
+		{404, "", allowAll},
+		{503, "", disallowAll},
+		{200, " ", emptyRobots},
+		{200, "# comment", emptyRobots},
+	} {
+		r, err := o.FromStatusAndString(c.status, c.body)
+		require.NoError(t, err)
+		assert.True(t, r != c.shared, "status %d modified a shared instance", c.status)
+		assert.Equal(t, "http://example.com", r.Origin())
+		assert.Equal(t, "", c.shared.Origin())
+		_, err = r.TestURLString("http://example.org/", "bot")
+		assert.True(t, errors.Is(err, ErrOriginMismatch))
+	}
+}
+
+func TestOriginFromResponse(t *testing.T) {
+	t.Parallel()
+	first, err := http.NewRequest("GET", "http://example.com/robots.txt", nil)
+	require.NoError(t, err)
+	second, err := http.NewRequest("GET", "https://cdn.example.net/robots.txt", nil)
+	require.NoError(t, err)
+	second.Response = &http.Response{StatusCode: 301, Request: first}
+	res := newHttpResponse(200, "User-agent: *\nDisallow: /x\n")
+	res.Request = second
+
+	r, err := FromResponse(res)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", r.Origin())
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	var fromJSON RobotsData
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, r.Origin(), fromJSON.Origin())
+
+	data, err = r.MarshalBinary()
+	require.NoError(t, err)
+	var fromBinary RobotsData
+	require.NoError(t, fromBinary.UnmarshalBinary(data))
+	assert.Equal(t, r.Origin(), fromBinary.Origin())
+}
@@ -0,0 +1,68 @@
+package robotstxt
+
+import "strings"
+
+// ProductToken extracts the robots.txt product token from a User-Agent
+// header, for example:
+//
+//	Googlebot/2.1 (+http://www.google.com/bot.html)                       -> Googlebot
+//	Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) -> bingbot
+//	Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.0; +https://openai.com/gptbot) -> GPTBot
+//
+// The first product of the header is used unless it is "Mozilla". Such
+// browser-like headers name the crawler in a comment, either as
+// "Name/version" or right after "compatible". Headers of most browsers
+// give "Mozilla".
+func ProductToken(userAgent string) string {
+	first, rest := nextProduct(userAgent)
+	if first != "" && !strings.EqualFold(first, "mozilla") {
+		return first
+	}
+	for rest != "" {
+		i := strings.IndexByte(rest, '(')
+		if i < 0 {
+			break
+		}
+		rest = rest[i+1:]
+		comment := rest
+		if j := strings.IndexByte(rest, ')'); j >= 0 {
+			comment, rest = rest[:j], rest[j+1:]
+		} else {
+			rest = ""
+		}
+		if token := commentProduct(comment); token != "" {
+			return token
+		}
+	}
+	return first
+}
+
+// nextProduct returns the token of the first product in header and the
+// remainder after it.
+func nextProduct(header string) (token, rest string) {
+	header = strings.TrimLeft(header, " \t")
+	token = leadingToken(header)
+	return token, header[len(token):]
+}
+
+// commentProduct finds crawler product in a User-Agent comment, items are
+// separated by ";".
+func commentProduct(comment string) string {
+	compatible := false
+	for _, item := range strings.Split(comment, ";") {
+		item = strings.TrimSpace(item)
+		token := leadingToken(item)
+		switch {
+		case token == "":
+		case strings.EqualFold(item, "compatible"):
+			compatible = true
+			continue
+		case compatible && len(token) == len(item):
+			return token
+		case strings.HasPrefix(item[len(token):], "/") && !strings.ContainsAny(item, " \t,"):
+			return token
+		}
+		compatible = false
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package robotstxt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userAgentHeaders = []struct {
+	header string
+	token  string
+}{
+	{"Googlebot/2.1 (+http://www.google.com/bot.html)", "Googlebot"},
+	{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Googlebot"},
+	{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; Googlebot/2.1; +http://www.google.com/bot.html) Chrome/120.0.6099.199 Safari/537.36", "Googlebot"},
+	{"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.199 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Googlebot"},
+	{"Googlebot-Image/1.0", "Googlebot-Image"},
+	{"Googlebot-Video/1.0", "Googlebot-Video"},
+	{"Mediapartners-Google", "Mediapartners-Google"},
+	{"AdsBot-Google (+http://www.google.com/adsbot.html)", "AdsBot-Google"},
+	{"Mozilla/5.0 (Linux; Android 5.0; SM-G920A) AppleWebKit (KHTML, like Gecko) Chrome Mobile Safari (compatible; AdsBot-Google-Mobile; +http://www.google.com/mobile/adsbot.html)", "AdsBot-Google-Mobile"},
+	{"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", "bingbot"},
+	{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36", "bingbot"},
+	{"Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)", "YandexBot"},
+	{"Mozilla/5.0 (compatible; Baiduspider/2.0; +http://www.baidu.com/search/spider.html)", "Baiduspider"},
+	{"DuckDuckBot/1.1; (+http://duckduckgo.com/duckduckbot.html)", "DuckDuckBot"},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_10_1) AppleWebKit/600.2.5 (KHTML, like Gecko) Version/8.0.2 Safari/600.2.5 (Applebot/0.1; +http://www.apple.com/go/applebot)", "Applebot"},
+	{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.0; +https://openai.com/gptbot)", "GPTBot"},
+	{"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", "AhrefsBot"},
+	{"Mozilla/5.0 (compatible; SemrushBot/7~bl; +http://www.semrush.com/bot.html)", "SemrushBot"},
+	{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", "facebookexternalhit"},
+	{"Twitterbot/1.0", "Twitterbot"},
+	{"curl/8.4.0", "curl"},
+	{"FooBot", "FooBot"},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Mozilla"},
+	{"Mozilla/5.0 (compatible; MSIE 9.0; Windows NT 6.1; Trident/5.0)", "Trident"},
+	{"", ""},
+}
+
+func TestProductToken(t *testing.T) {
+	t.Parallel()
+	for _, c := range userAgentHeaders {
+		assert.Equal(t, c.token, ProductToken(c.header), c.header)
+	}
+}
+
+func TestFullUserAgent(t *testing.T) {
+	t.Parallel()
+	const robots = `User-agent: googlebot
+Disallow: /google
+
+User-agent: bingbot
+Disallow: /bing
+
+User-agent: *
+Disallow: /all
+`
+	for _, o := range []Options{{FullUserAgent: true}, {FullUserAgent: true, RFC9309: true}} {
+		r, err := o.FromString(robots)
+		require.NoError(t, err)
+		plain, err := Options{RFC9309: o.RFC9309}.FromString(robots)
+		require.NoError(t, err)
+		for _, c := range userAgentHeaders {
+			assert.Equal(t, plain.FindGroup(c.token).Agent, r.FindGroup(c.header).Agent, c.header)
+		}
+		header := "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+		expectAccess(t, r, false, "/google", header)
+		expectAccess(t, r, true, "/all", header)
+		assert.Equal(t, "googlebot", r.Explain("/", header).Agent)
+		expectAccess(t, plain, true, "/google", header)
+	}
+}